@@ -0,0 +1,96 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goracle
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/goracle.v1/oracle"
+)
+
+// ExecMany executes qry once per row of a column-oriented batch using a
+// single OCI array bind (one OCIStmtExecute with iters=len(args[i]))
+// instead of one round-trip per row. args is indexed by bind position,
+// each element holding one column's values in row order; all columns
+// must have the same length, and each column's values must all share
+// one of the types oracle.Cursor.ExecuteMany supports (int64, float64,
+// string, time.Time).
+//
+// It returns the number of rows the server accepted. On a partial
+// failure -- some rows rejected by the server, e.g. a constraint
+// violation on one row of the batch -- the returned count only covers
+// the rows that went through, and the error is an *oracle.ExecuteManyError
+// naming the rest.
+func (c *Conn) ExecMany(qry string, args [][]driver.Value) (int64, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+	n := len(args[0])
+	for i, col := range args {
+		if len(col) != n {
+			return 0, fmt.Errorf("goracle: ExecMany: bind %d has %d values, want %d", i, len(col), n)
+		}
+	}
+
+	params := make([]map[string]interface{}, n)
+	for row := 0; row < n; row++ {
+		p := make(map[string]interface{}, len(args))
+		for i, col := range args {
+			p[strconv.Itoa(i+1)] = col[row]
+		}
+		params[row] = p
+	}
+
+	cur := c.Connection.NewCursor()
+	defer cur.Close()
+
+	if err := cur.ExecuteMany(qry, params); err != nil {
+		var batchErr *oracle.ExecuteManyError
+		if errors.As(err, &batchErr) {
+			return int64(n - len(batchErr.Failed)), fmt.Errorf("goracle: ExecMany: %w", err)
+		}
+		return 0, fmt.Errorf("goracle: ExecMany: %s", err)
+	}
+	return int64(n), nil
+}
+
+// ManyStmt is the prepared-statement counterpart of (*Conn).ExecMany: it
+// keeps hold of the query text and connection so ExecMany can be called
+// repeatedly with different batches. It holds no server-side statement:
+// each ExecMany call opens a new cursor and parses the query again.
+type ManyStmt struct {
+	conn  *Conn
+	query string
+}
+
+// PrepareMany returns a ManyStmt bound to qry on this connection.
+// database/sql gives drivers no access to an already-prepared
+// driver.Stmt's query text, so unlike sql.Stmt, ManyStmt is created
+// directly from a *Conn (see (*sql.Conn).Raw) rather than wrapping an
+// existing *sql.Stmt.
+func (c *Conn) PrepareMany(qry string) *ManyStmt {
+	return &ManyStmt{conn: c, query: qry}
+}
+
+// ExecMany runs one array-bind execution of the prepared query for this
+// batch; see (*Conn).ExecMany for the batch semantics.
+func (s *ManyStmt) ExecMany(args [][]driver.Value) (int64, error) {
+	return s.conn.ExecMany(s.query, args)
+}
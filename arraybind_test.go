@@ -0,0 +1,67 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goracle
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestExecMany(t *testing.T) {
+	db := getConnection(t)
+
+	if _, err := db.Exec("CREATE TABLE goracle_exec_many_test (id NUMBER, txt VARCHAR2(40))"); err != nil {
+		t.Skip("cannot create scratch table: ", err)
+	}
+	defer db.Exec("DROP TABLE goracle_exec_many_test")
+
+	sqlConn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlConn.Close()
+
+	ids := []driver.Value{int64(1), int64(2), int64(3)}
+	txts := []driver.Value{"one", "two", "three"}
+
+	if err := sqlConn.Raw(func(raw interface{}) error {
+		c, ok := raw.(*Conn)
+		if !ok {
+			t.Skip("driver connection does not support ExecMany")
+		}
+		n, err := c.ExecMany("INSERT INTO goracle_exec_many_test (id, txt) VALUES (:1, :2)",
+			[][]driver.Value{ids, txts})
+		if err != nil {
+			return err
+		}
+		if n != int64(len(ids)) {
+			t.Errorf("RowsAffected=%d, want %d", n, len(ids))
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("ExecMany: %s", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM goracle_exec_many_test").Scan(&count); err != nil {
+		t.Fatalf("count: %s", err)
+	}
+	if count != len(ids) {
+		t.Errorf("count=%d, want %d", count, len(ids))
+	}
+}
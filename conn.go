@@ -0,0 +1,144 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goracle
+
+/*
+#include <oci.h>
+*/
+import "C"
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"sync"
+	"unsafe"
+
+	"gopkg.in/goracle.v1/oracle"
+)
+
+// Conn is the driver.Conn goracle hands back from sql.Open/sql.DB.Conn.
+// It wraps the lower-level oracle.Connection and is the anchor point
+// for goracle features that need the raw OCI handles (Subscribe,
+// BeginTx, ExecMany, ...) via the unexported helpers below.
+//
+// Applications don't normally see *Conn directly; they reach it through
+// (*sql.Conn).Raw in order to call the goracle-specific methods that
+// aren't part of database/sql/driver.
+type Conn struct {
+	*oracle.Connection
+
+	// typesMu guards types, this connection's ObjectTypeFor cache (see
+	// object.go). It must live on *Conn, not a package-level map: a
+	// cached ObjectType's tdo is a raw OCIType* scoped to the OCI
+	// environment/service context lookupObjectType resolved it on, so
+	// sharing the cache across connections (let alone across a closed and
+	// reopened one) hands out TDO handles from a foreign, possibly freed,
+	// environment.
+	typesMu sync.Mutex
+	types   map[string]*ObjectType
+}
+
+var (
+	_ driver.Conn   = (*Conn)(nil)
+	_ driver.Pinger = (*Conn)(nil)
+)
+
+// Ping reports whether the underlying connection is still usable.
+func (c *Conn) Ping(ctx context.Context) error {
+	return c.Connection.Ping()
+}
+
+// envHandle, svcCtx and errHandle expose the raw OCI handles backing
+// this connection, for the cgo-heavy features implemented alongside
+// this type (see cqn.go, arraybind.go, tx.go, object.go). They delegate
+// to the EnvHandle/SvcCtxHandle/ErrorHandle accessors on oracle.Connection.
+func (c *Conn) envHandle() *C.OCIEnv   { return (*C.OCIEnv)(c.Connection.EnvHandle()) }
+func (c *Conn) svcCtx() *C.OCISvcCtx   { return (*C.OCISvcCtx)(c.Connection.SvcCtxHandle()) }
+func (c *Conn) errHandle() *C.OCIError { return (*C.OCIError)(c.Connection.ErrorHandle()) }
+
+// oracleError wraps an OCI return code with the error text fetched via
+// OCIErrorGet, in the same "FUNC: message (ORA-nnnnn)" shape
+// oracle.Connection already uses for its own errors.
+func (c *Conn) oracleError(function string, rv C.sword) error {
+	var buf [2048]C.char
+	var errcode C.sb4
+	C.OCIErrorGet(unsafe.Pointer(c.errHandle()), 1, nil, &errcode,
+		(*C.OraText)(unsafe.Pointer(&buf[0])), C.ub4(len(buf)), C.OCI_HTYPE_ERROR)
+	return fmt.Errorf("goracle: %s: %s (ORA-%05d)", function, C.GoString(&buf[0]), int(errcode))
+}
+
+// setSubscrAttr sets a ub4-valued attribute on an OCISubscription handle
+// via OCIAttrSet.
+func (c *Conn) setSubscrAttr(h *C.OCISubscription, attr C.ub4, v int) error {
+	cv := C.ub4(v)
+	rv := C.OCIAttrSet(unsafe.Pointer(h), C.OCI_HTYPE_SUBSCRIPTION,
+		unsafe.Pointer(&cv), 0, attr, c.errHandle())
+	if rv != C.OCI_SUCCESS {
+		return c.oracleError("OCIAttrSet(subscription)", rv)
+	}
+	return nil
+}
+
+// setSubscrCallback wires OCI_ATTR_SUBSCR_CALLBACK/CTX to the package's
+// cgo trampoline and this subscription's registry id, so
+// goracleCQNCallback can find its way back to the right Subscription.
+func (c *Conn) setSubscrCallback(h *C.OCISubscription, id uint64) error {
+	rv := C.OCIAttrSet(unsafe.Pointer(h), C.OCI_HTYPE_SUBSCRIPTION,
+		unsafe.Pointer(C.goracleCQNCallbackTrampoline), 0, C.OCI_ATTR_SUBSCR_CALLBACK, c.errHandle())
+	if rv != C.OCI_SUCCESS {
+		return c.oracleError("OCIAttrSet(OCI_ATTR_SUBSCR_CALLBACK)", rv)
+	}
+	ctx := unsafe.Pointer(uintptr(id))
+	rv = C.OCIAttrSet(unsafe.Pointer(h), C.OCI_HTYPE_SUBSCRIPTION, ctx, 0, C.OCI_ATTR_SUBSCR_CTX, c.errHandle())
+	if rv != C.OCI_SUCCESS {
+		return c.oracleError("OCIAttrSet(OCI_ATTR_SUBSCR_CTX)", rv)
+	}
+	return nil
+}
+
+// registerQuery runs qry under this connection so Oracle knows which
+// rows a just-created subscription should watch; the result set itself
+// is discarded. args are bound positionally, matching the ":1", ":2", ...
+// placeholders oracle.Cursor.Execute expects.
+func (c *Conn) registerQuery(qry string, args []interface{}) error {
+	cur := c.Connection.NewCursor()
+	defer cur.Close()
+	params := make(map[string]interface{}, len(args))
+	for i, a := range args {
+		params[strconv.Itoa(i+1)] = a
+	}
+	return cur.Execute(qry, params)
+}
+
+// ExecOut runs a PL/SQL block (or any statement with named ":1", ":2",
+// ... binds) that reports results through OUT parameters rather than a
+// result set, e.g. "BEGIN :1 := DBMS_LOCK.REQUEST(:2, ...); END;".
+// params holds one entry per bind name; pointer values (*int64, *string)
+// are bound for IN/OUT and are overwritten with whatever Oracle assigned
+// to them once ExecOut returns, same as oracle.Cursor.Execute itself.
+//
+// database/sql has no bind mechanism for this (sql.Out needs driver
+// support goracle doesn't implement at the database/sql layer), so
+// callers that need OUT parameters reach *Conn directly through
+// (*sql.Conn).Raw, the same way Subscribe and ExecMany do.
+func (c *Conn) ExecOut(qry string, params map[string]interface{}) error {
+	cur := c.Connection.NewCursor()
+	defer cur.Close()
+	return cur.Execute(qry, params)
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goracle
+
+/*
+#include <oci.h>
+#include <stdlib.h>
+
+extern void goracleCQNCallback(void *ctx, OCISubscription *subscrhp, void *payload,
+                                ub4 *payloadl, void *desc, ub4 mode);
+
+static void goracleCQNCallbackTrampoline(dvoid *ctx, OCISubscription *subscrhp,
+                                         dvoid *payload, ub4 *payloadl,
+                                         dvoid *desc, ub4 mode) {
+	goracleCQNCallback(ctx, subscrhp, payload, payloadl, desc, mode);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// Operation is the DML operation that triggered a ChangeEvent.
+type Operation int
+
+const (
+	OpInsert Operation = 1 << iota
+	OpUpdate
+	OpDelete
+)
+
+// SubscribeOptions configures a CQN registration made with Subscribe.
+type SubscribeOptions struct {
+	// Query is the SELECT statement Oracle should track for changes. It
+	// is executed once, under the registered subscription, so the
+	// database knows which rows/objects to watch.
+	Query string
+	Args  []interface{}
+
+	// RowIDs requests row-level change information (OCI_SUBSCR_QOS_ROWIDS)
+	// in addition to the table name.
+	RowIDs bool
+	// Reliable requests OCI_SUBSCR_QOS_RELIABLE, so events survive a
+	// dropped connection and are replayed once it reconnects.
+	Reliable bool
+	// GroupingWindow, if non-zero, batches notifications that occur
+	// within the window into a single callback (OCI_ATTR_SUBSCR_TIMEOUT).
+	GroupingWindow time.Duration
+}
+
+// ChangeEvent is one notification delivered for a Subscription: a single
+// table changed by a single operation, optionally with the affected
+// ROWIDs.
+type ChangeEvent struct {
+	Table     string
+	Operation Operation
+	RowIDs    []string
+}
+
+// Subscription is a live Oracle Continuous Query Notification
+// registration. Events arrive on the channel returned by Events until
+// Close is called or the Connection is closed.
+type Subscription struct {
+	conn    *Conn
+	handle  *C.OCISubscription
+	id      uint64
+	events  chan ChangeEvent
+	closing chan struct{}
+	once    sync.Once
+}
+
+// Events returns the channel ChangeEvents are delivered on. It is closed
+// when the Subscription is Closed.
+func (s *Subscription) Events() <-chan ChangeEvent { return s.events }
+
+// Close unregisters the subscription (OCISubscriptionUnRegister) and
+// closes the Events channel. Close is idempotent.
+func (s *Subscription) Close() error {
+	var err error
+	s.once.Do(func() {
+		cqnRegistry.delete(s.id)
+		close(s.closing)
+		rv := C.OCISubscriptionUnRegister(s.conn.svcCtx(), s.handle, s.conn.errHandle(), C.OCI_DEFAULT)
+		if rv != C.OCI_SUCCESS {
+			err = s.conn.oracleError("OCISubscriptionUnRegister", rv)
+		}
+		close(s.events)
+	})
+	return err
+}
+
+// Subscribe registers opts.Query for change notification and returns a
+// Subscription whose Events channel receives a ChangeEvent every time
+// Oracle reports the watched rows changed.
+//
+// The underlying connection must stay open for the lifetime of the
+// Subscription; closing it implicitly cancels any subscriptions it
+// still holds.
+func (c *Conn) Subscribe(opts SubscribeOptions) (*Subscription, error) {
+	if opts.Query == "" {
+		return nil, fmt.Errorf("goracle: Subscribe: empty Query")
+	}
+
+	s := &Subscription{
+		conn:    c,
+		id:      cqnRegistry.nextID(),
+		events:  make(chan ChangeEvent, 16),
+		closing: make(chan struct{}),
+	}
+
+	// s.handle is a Go *C.OCISubscription, so &s.handle is a real
+	// OCISubscription** (pointer to where OCI should write the new handle
+	// pointer it allocates) -- not the address of the opaque struct
+	// itself, which would let OCIHandleAlloc scribble a pointer-sized
+	// value over the Subscription fields that follow it.
+	rv := C.OCIHandleAlloc(unsafe.Pointer(c.envHandle()), (*unsafe.Pointer)(unsafe.Pointer(&s.handle)),
+		C.OCI_HTYPE_SUBSCRIPTION, 0, nil)
+	if rv != C.OCI_SUCCESS {
+		return nil, c.oracleError("OCIHandleAlloc(OCI_HTYPE_SUBSCRIPTION)", rv)
+	}
+
+	if err := c.setSubscrAttr(s.handle, C.OCI_ATTR_SUBSCR_NAMESPACE, int(C.OCI_SUBSCR_NAMESPACE_DBCHANGE)); err != nil {
+		return nil, err
+	}
+
+	var qosFlags C.ub4
+	if opts.RowIDs {
+		qosFlags |= C.OCI_SUBSCR_QOS_ROWIDS
+	}
+	if opts.Reliable {
+		qosFlags |= C.OCI_SUBSCR_QOS_RELIABLE
+	}
+	if err := c.setSubscrAttr(s.handle, C.OCI_ATTR_SUBSCR_QOSFLAGS, int(qosFlags)); err != nil {
+		return nil, err
+	}
+	if opts.GroupingWindow > 0 {
+		if err := c.setSubscrAttr(s.handle, C.OCI_ATTR_SUBSCR_TIMEOUT, int(opts.GroupingWindow/time.Second)); err != nil {
+			return nil, err
+		}
+	}
+
+	cqnRegistry.put(s.id, s)
+	if err := c.setSubscrCallback(s.handle, s.id); err != nil {
+		cqnRegistry.delete(s.id)
+		return nil, err
+	}
+
+	if rv := C.OCISubscriptionRegister(c.svcCtx(), &s.handle, 1, c.errHandle(), C.OCI_DEFAULT); rv != C.OCI_SUCCESS {
+		cqnRegistry.delete(s.id)
+		return nil, c.oracleError("OCISubscriptionRegister", rv)
+	}
+
+	// Run the tracked query under this subscription so the server knows
+	// which rows to watch; the result set itself is discarded.
+	if err := c.registerQuery(opts.Query, opts.Args); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("goracle: Subscribe: register query: %s", err)
+	}
+
+	return s, nil
+}
+
+// cqnSubscriptions maps a subscription id (passed through as the cgo
+// callback context) back to the Go Subscription it belongs to, since cgo
+// callbacks can't carry a Go pointer directly.
+type cqnSubscriptions struct {
+	mu   sync.Mutex
+	next uint64
+	m    map[uint64]*Subscription
+}
+
+var cqnRegistry = &cqnSubscriptions{m: make(map[uint64]*Subscription)}
+
+func (r *cqnSubscriptions) nextID() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.next++
+	return r.next
+}
+
+func (r *cqnSubscriptions) put(id uint64, s *Subscription) {
+	r.mu.Lock()
+	r.m[id] = s
+	r.mu.Unlock()
+}
+
+func (r *cqnSubscriptions) delete(id uint64) {
+	r.mu.Lock()
+	delete(r.m, id)
+	r.mu.Unlock()
+}
+
+func (r *cqnSubscriptions) get(id uint64) *Subscription {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.m[id]
+}
+
+//export goracleCQNCallback
+func goracleCQNCallback(ctx unsafe.Pointer, subscrhp *C.OCISubscription, payload unsafe.Pointer,
+	payloadl *C.ub4, desc unsafe.Pointer, mode C.ub4) {
+	id := uint64(uintptr(ctx))
+	s := cqnRegistry.get(id)
+	if s == nil {
+		return
+	}
+	ev, err := decodeChangeDescriptor(s.conn.errHandle(), desc)
+	if err != nil {
+		return
+	}
+	select {
+	case s.events <- ev:
+	case <-s.closing:
+	}
+}
+
+// descAttrGet fetches a descriptor's attribute as raw bytes, via
+// OCIAttrGet. dtype is the handle/descriptor type the attribute belongs
+// to (e.g. OCI_DTYPE_TABLE_CHDES).
+func descAttrGet(errh *C.OCIError, d unsafe.Pointer, dtype C.ub4, attr C.ub4) (unsafe.Pointer, C.ub4, error) {
+	var valuep unsafe.Pointer
+	var size C.ub4
+	rv := C.OCIAttrGet(d, dtype, unsafe.Pointer(&valuep), &size, attr, errh)
+	if rv != C.OCI_SUCCESS {
+		return nil, 0, fmt.Errorf("goracle: OCIAttrGet(attr=%d): rv=%d", int(attr), int(rv))
+	}
+	return valuep, size, nil
+}
+
+// collElem fetches element i of an OCIColl (0-based), as the opaque
+// descriptor/handle pointer OCICollGetElem hands back.
+func collElem(errh *C.OCIError, env *C.OCIEnv, coll *C.OCIColl, i C.sb4) (unsafe.Pointer, error) {
+	var exists C.boolean
+	var elemIndp unsafe.Pointer
+	var elem unsafe.Pointer
+	rv := C.OCICollGetElem((*C.OCIEnv)(unsafe.Pointer(env)), errh, (*C.OCIColl)(coll), i, &exists, &elem, &elemIndp)
+	if rv != C.OCI_SUCCESS {
+		return nil, fmt.Errorf("goracle: OCICollGetElem(%d): rv=%d", int(i), int(rv))
+	}
+	return elem, nil
+}
+
+// decodeChangeDescriptor reads the changed table's name, the DML
+// operation and (if OCI_SUBSCR_QOS_ROWIDS was requested) the affected
+// ROWIDs out of an OCI_DTYPE_CHDES descriptor delivered to the CQN
+// callback. Only the first changed table is reported; Oracle delivers
+// one callback per table, so in practice the table collection holds a
+// single element for row-level notifications.
+func decodeChangeDescriptor(errh *C.OCIError, desc unsafe.Pointer) (ChangeEvent, error) {
+	var ev ChangeEvent
+
+	tcPtr, _, err := descAttrGet(errh, desc, C.OCI_DTYPE_CHDES, C.OCI_ATTR_CHDES_TABLE_CHANGES)
+	if err != nil || tcPtr == nil {
+		return ev, err
+	}
+	tableColl := (*C.OCIColl)(tcPtr)
+
+	var n C.sb4
+	if rv := C.OCICollSize(nil, errh, tableColl, &n); rv != C.OCI_SUCCESS {
+		return ev, fmt.Errorf("goracle: OCICollSize: rv=%d", int(rv))
+	}
+	if n == 0 {
+		return ev, nil
+	}
+	tableDesc, err := collElem(errh, nil, tableColl, 0)
+	if err != nil {
+		return ev, err
+	}
+
+	namep, namelen, err := descAttrGet(errh, tableDesc, C.OCI_DTYPE_TABLE_CHDES, C.OCI_ATTR_CHDES_TABLE_NAME)
+	if err != nil {
+		return ev, err
+	}
+	if namep != nil {
+		ev.Table = C.GoStringN((*C.char)(namep), C.int(namelen))
+	}
+
+	opflagsp, _, err := descAttrGet(errh, tableDesc, C.OCI_DTYPE_TABLE_CHDES, C.OCI_ATTR_CHDES_TABLE_OPFLAGS)
+	if err != nil {
+		return ev, err
+	}
+	opflags := *(*C.ub4)(opflagsp)
+	switch {
+	case opflags&C.OCI_OPCODE_INSERT != 0:
+		ev.Operation = OpInsert
+	case opflags&C.OCI_OPCODE_UPDATE != 0:
+		ev.Operation = OpUpdate
+	case opflags&C.OCI_OPCODE_DELETE != 0:
+		ev.Operation = OpDelete
+	}
+
+	rcPtr, _, err := descAttrGet(errh, tableDesc, C.OCI_DTYPE_TABLE_CHDES, C.OCI_ATTR_CHDES_TABLE_ROW_CHANGES)
+	if err != nil || rcPtr == nil {
+		return ev, nil
+	}
+	rowColl := (*C.OCIColl)(rcPtr)
+	var nrows C.sb4
+	if rv := C.OCICollSize(nil, errh, rowColl, &nrows); rv != C.OCI_SUCCESS || nrows == 0 {
+		return ev, nil
+	}
+	ev.RowIDs = make([]string, 0, int(nrows))
+	for i := C.sb4(0); i < nrows; i++ {
+		rowDesc, err := collElem(errh, nil, rowColl, i)
+		if err != nil {
+			continue
+		}
+		ridp, _, err := descAttrGet(errh, rowDesc, C.OCI_DTYPE_ROW_CHDES, C.OCI_ATTR_CHDES_ROW_ROWID)
+		if err != nil || ridp == nil {
+			continue
+		}
+		var buf [256]C.char
+		buflen := C.ub2(len(buf))
+		if rv := C.OCIRowidToChar((*C.OCIRowid)(ridp), (*C.OraText)(unsafe.Pointer(&buf[0])), &buflen, errh); rv != C.OCI_SUCCESS {
+			continue
+		}
+		ev.RowIDs = append(ev.RowIDs, C.GoStringN(&buf[0], C.int(buflen)))
+	}
+
+	return ev, nil
+}
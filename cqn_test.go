@@ -0,0 +1,78 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goracle
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	db := getConnection(t)
+
+	if _, err := db.Exec("CREATE TABLE goracle_cqn_test (id NUMBER)"); err != nil {
+		t.Skip("cannot create scratch table (needs CHANGE NOTIFICATION privilege): ", err)
+	}
+	defer db.Exec("DROP TABLE goracle_cqn_test")
+
+	sqlConn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlConn.Close()
+
+	var sub *Subscription
+	if err := sqlConn.Raw(func(raw interface{}) error {
+		c, ok := raw.(*Conn)
+		if !ok {
+			t.Skip("driver connection does not support Subscribe")
+		}
+		var err error
+		sub, err = c.Subscribe(SubscribeOptions{
+			Query:    "SELECT id FROM goracle_cqn_test",
+			RowIDs:   true,
+			Reliable: true,
+		})
+		return err
+	}); err != nil {
+		t.Fatalf("Subscribe: %s", err)
+	}
+	defer sub.Close()
+
+	if _, err := db.Exec("INSERT INTO goracle_cqn_test (id) VALUES (1)"); err != nil {
+		t.Fatalf("insert: %s", err)
+	}
+	if _, err := db.Exec("COMMIT"); err != nil {
+		t.Fatalf("commit: %s", err)
+	}
+
+	select {
+	case ev := <-sub.Events():
+		if ev.Table == "" {
+			t.Error("change event has no table name")
+		}
+		if ev.Operation != OpInsert {
+			t.Errorf("Operation = %v, want OpInsert", ev.Operation)
+		}
+		if len(ev.RowIDs) == 0 {
+			t.Error("change event has no RowIDs, want at least the inserted row's")
+		}
+	case <-time.After(10 * time.Second):
+		t.Error("timed out waiting for a change notification")
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goracle
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+// Driver implements database/sql/driver.Driver, registered under the
+// "goracle" name so callers can just sql.Open("goracle", dsn).
+type Driver struct{}
+
+var _ driver.Driver = Driver{}
+
+func init() {
+	sql.Register("goracle", Driver{})
+}
+
+// Open parses dsn (see ParseDSN) and opens a new OCI session for it.
+func (Driver) Open(dsn string) (driver.Conn, error) {
+	d, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("goracle: Open: %s", err)
+	}
+	conn, err := openDSN(d)
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{Connection: conn}, nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goracle
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DSN is a parsed goracle connection string. Besides the classic
+// "user/password@host:port/service" EZCONNECT form, the parser accepts
+// a trailing "?key=value&..." query string carrying wallet/TCPS
+// options, and a raw connect descriptor
+// ("(DESCRIPTION=(ADDRESS=...)...)") in place of host:port/service.
+type DSN struct {
+	Username, Password string
+
+	// Either ConnectDescriptor is set (a raw Oracle connect descriptor),
+	// or Host/Port/Service are, and EZCONNECT-style descriptor is built
+	// from them.
+	ConnectDescriptor string
+	Host              string
+	Port              int
+	Service           string
+
+	// TCPS requests a TLS (TCPS) connection instead of plain TCP.
+	TCPS bool
+	// Wallet is the directory holding cwallet.sso/ewallet.p12 for
+	// mutual-TLS auth; set together with TCPS.
+	Wallet         string
+	WalletPassword string
+	// SSLServerCertDN, if set, is matched against the server's
+	// certificate distinguished name (OCI_ATTR_SSL_SERVER_DN_MATCH).
+	SSLServerCertDN string
+}
+
+// ParseDSN parses a goracle DSN. The accepted shapes are:
+//
+//	user/password@host:port/service
+//	user/password@host:port/service?wallet=/path&tcps=true
+//	user/password@(DESCRIPTION=(ADDRESS=(PROTOCOL=TCPS)(HOST=h)(PORT=p))(CONNECT_DATA=(SERVICE_NAME=s)))?wallet=/path
+func ParseDSN(dsn string) (DSN, error) {
+	var d DSN
+
+	rest := dsn
+	if q := strings.IndexByte(rest, '?'); q >= 0 {
+		values, err := url.ParseQuery(rest[q+1:])
+		if err != nil {
+			return d, fmt.Errorf("goracle: parse DSN options: %s", err)
+		}
+		rest = rest[:q]
+		d.Wallet = values.Get("wallet")
+		d.WalletPassword = values.Get("walletPassword")
+		d.SSLServerCertDN = values.Get("sslServerCertDN")
+		if v := values.Get("tcps"); v != "" {
+			tcps, err := strconv.ParseBool(v)
+			if err != nil {
+				return d, fmt.Errorf("goracle: parse DSN option tcps=%q: %s", v, err)
+			}
+			d.TCPS = tcps
+		}
+	}
+
+	userinfo := rest
+	connect := ""
+	if at := strings.IndexByte(rest, '@'); at >= 0 {
+		userinfo = rest[:at]
+		connect = rest[at+1:]
+	}
+	if slash := strings.IndexByte(userinfo, '/'); slash >= 0 {
+		d.Username, d.Password = userinfo[:slash], userinfo[slash+1:]
+	} else {
+		d.Username = userinfo
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(connect), "(") {
+		d.ConnectDescriptor = connect
+		return d, nil
+	}
+
+	hostport, service := connect, ""
+	if slash := strings.IndexByte(connect, '/'); slash >= 0 {
+		hostport, service = connect[:slash], connect[slash+1:]
+	}
+	host, portStr := hostport, ""
+	if colon := strings.IndexByte(hostport, ':'); colon >= 0 {
+		host, portStr = hostport[:colon], hostport[colon+1:]
+	}
+	d.Host, d.Service = host, service
+	if portStr != "" {
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return d, fmt.Errorf("goracle: parse DSN port %q: %s", portStr, err)
+		}
+		d.Port = port
+	}
+	if d.Wallet != "" {
+		d.TCPS = true
+	}
+	return d, nil
+}
+
+// Descriptor returns the Oracle connect descriptor this DSN resolves
+// to, building an EZCONNECT-style "(DESCRIPTION=...)" from
+// Host/Port/Service/TCPS when ConnectDescriptor wasn't given explicitly.
+func (d DSN) Descriptor() string {
+	if d.ConnectDescriptor != "" {
+		return d.ConnectDescriptor
+	}
+	protocol := "TCP"
+	if d.TCPS {
+		protocol = "TCPS"
+	}
+	port := d.Port
+	if port == 0 {
+		port = 1521
+	}
+	return fmt.Sprintf(
+		"(DESCRIPTION=(ADDRESS=(PROTOCOL=%s)(HOST=%s)(PORT=%d))(CONNECT_DATA=(SERVICE_NAME=%s)))",
+		protocol, d.Host, port, d.Service)
+}
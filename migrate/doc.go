@@ -0,0 +1,31 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package migrate implements the github.com/golang-migrate/migrate
+// database.Driver interface for Oracle, on top of the goracle driver.
+//
+// Oracle has no advisory lock primitive, so Lock/Unlock use
+// DBMS_LOCK.ALLOCATE_UNIQUE/REQUEST/RELEASE with a lock name derived
+// from the migrations table name; migration state is tracked in a
+// schema_migrations table this package creates on first use.
+//
+//	import (
+//		"github.com/golang-migrate/migrate/v4"
+//		_ "github.com/vsdutka/goracle-1/migrate"
+//	)
+//
+//	m, err := migrate.New("file://migrations", "goracle://user/pwd@host/service")
+package migrate
@@ -0,0 +1,300 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
+
+	goracle "github.com/vsdutka/goracle-1" // registers the "goracle" database/sql driver
+)
+
+func init() {
+	database.Register("goracle", &Oracle{})
+}
+
+const defaultMigrationsTable = "schema_migrations"
+
+// Config holds the options a "goracle://" migrate URL can carry as
+// query parameters, e.g. "goracle://user/pwd@host/svc?x-migrations-table=foo".
+type Config struct {
+	MigrationsTable string
+	// StatementTimeout bounds how long a single migration statement may
+	// run; exceeding it aborts the statement with OCIBreak/OCIReset
+	// (oracle.Connection.Cancel) on the connection running it.
+	StatementTimeout time.Duration
+}
+
+// Oracle implements database.Driver for Oracle, for use with
+// github.com/golang-migrate/migrate.
+type Oracle struct {
+	db         *sql.DB
+	config     *Config
+	lockHandle string
+}
+
+// WithInstance returns an Oracle driver instance for an already-open
+// *sql.DB, for callers who manage the connection pool themselves instead
+// of letting migrate.Open parse a URL.
+func WithInstance(db *sql.DB, config *Config) (database.Driver, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	if config.MigrationsTable == "" {
+		config.MigrationsTable = defaultMigrationsTable
+	}
+	o := &Oracle{db: db, config: config}
+	if err := o.ensureVersionTable(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// WithStatementTimeout returns a copy of o that aborts any migration
+// statement taking longer than d.
+func (o *Oracle) WithStatementTimeout(d time.Duration) *Oracle {
+	cp := *o
+	cfg := *o.config
+	cfg.StatementTimeout = d
+	cp.config = &cfg
+	return &cp
+}
+
+// Open implements database.Driver: it parses dsnURL (a "goracle://"
+// migrate URL), opens a *sql.DB and ensures schema_migrations exists.
+func (o *Oracle) Open(dsnURL string) (database.Driver, error) {
+	u, err := url.Parse(dsnURL)
+	if err != nil {
+		return nil, fmt.Errorf("goracle/migrate: parse %q: %s", dsnURL, err)
+	}
+	dsn := fmt.Sprintf("%s%s", u.Host, u.Path)
+
+	db, err := sql.Open("goracle", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("goracle/migrate: open: %s", err)
+	}
+
+	config := &Config{MigrationsTable: u.Query().Get("x-migrations-table")}
+	if config.MigrationsTable == "" {
+		config.MigrationsTable = defaultMigrationsTable
+	}
+	return WithInstance(db, config)
+}
+
+// Close implements database.Driver.
+func (o *Oracle) Close() error {
+	return o.db.Close()
+}
+
+// lockName derives a DBMS_LOCK.ALLOCATE_UNIQUE handle name for this
+// migrations table, so independent migrate runs against the same schema
+// serialize on the same advisory lock.
+func (o *Oracle) lockName() string {
+	h := fnv.New32a()
+	_, _ = io.WriteString(h, "goracle-migrate-"+o.config.MigrationsTable)
+	return fmt.Sprintf("goracle_migrate_%08x", h.Sum32())
+}
+
+// Lock implements database.Driver. Oracle has no advisory-lock
+// primitive of its own, so this uses DBMS_LOCK.REQUEST against a handle
+// allocated with DBMS_LOCK.ALLOCATE_UNIQUE for this migrations table,
+// blocking (exclusive X_MODE, timeout DBMS_LOCK.MAXWAIT = no time limit;
+// StatementTimeout does not apply here) until any other migrate run
+// against the same schema releases it.
+//
+// Both calls report their result through an OUT/assignment bind
+// (ALLOCATE_UNIQUE's second parameter, and REQUEST's function return),
+// and database/sql gives drivers no way to populate a Go variable from
+// an OUT bind. So this runs through the raw *goracle.Conn reachable via
+// (*sql.Conn).Raw, the same way other goracle-specific features do,
+// using ExecOut's pointer-bind convention instead of db.QueryRow/Scan.
+func (o *Oracle) Lock() error {
+	var handle string
+	var result int64
+	err := o.withRawConn(func(c *goracle.Conn) error {
+		if err := c.ExecOut("BEGIN DBMS_LOCK.ALLOCATE_UNIQUE(:1, :2); END;",
+			map[string]interface{}{"1": o.lockName(), "2": &handle}); err != nil {
+			return fmt.Errorf("allocate lock: %s", err)
+		}
+		return c.ExecOut("BEGIN :1 := DBMS_LOCK.REQUEST(:2, DBMS_LOCK.X_MODE, DBMS_LOCK.MAXWAIT, TRUE); END;",
+			map[string]interface{}{"1": &result, "2": handle})
+	})
+	if err != nil {
+		return fmt.Errorf("goracle/migrate: %s", err)
+	}
+	if result != 0 && result != 4 {
+		return fmt.Errorf("goracle/migrate: DBMS_LOCK.REQUEST returned %d", result)
+	}
+	o.lockHandle = handle
+	return nil
+}
+
+// Unlock implements database.Driver.
+func (o *Oracle) Unlock() error {
+	if o.lockHandle == "" {
+		return nil
+	}
+	return o.withRawConn(func(c *goracle.Conn) error {
+		return c.ExecOut("BEGIN DBMS_LOCK.RELEASE(:1); END;", map[string]interface{}{"1": o.lockHandle})
+	})
+}
+
+// withRawConn runs fn against the *goracle.Conn backing a fresh
+// connection from o.db's pool, for the handful of operations (OUT-bind
+// locking) that need goracle-specific methods database/sql itself
+// doesn't expose.
+func (o *Oracle) withRawConn(fn func(c *goracle.Conn) error) error {
+	sqlConn, err := o.db.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer sqlConn.Close()
+	return sqlConn.Raw(func(raw interface{}) error {
+		c, ok := raw.(*goracle.Conn)
+		if !ok {
+			return fmt.Errorf("driver connection does not support ExecOut")
+		}
+		return fn(c)
+	})
+}
+
+// Run implements database.Driver: it splits migration on PL/SQL-aware
+// boundaries and executes each statement in turn.
+func (o *Oracle) Run(migration io.Reader) error {
+	script, err := ioutil.ReadAll(migration)
+	if err != nil {
+		return fmt.Errorf("goracle/migrate: read migration: %s", err)
+	}
+	for _, stmt := range SplitStatements(string(script)) {
+		if err := o.execStatement(stmt); err != nil {
+			return fmt.Errorf("goracle/migrate: %s", err)
+		}
+	}
+	return nil
+}
+
+func (o *Oracle) execStatement(stmt string) error {
+	if o.config.StatementTimeout <= 0 {
+		_, err := o.db.Exec(stmt)
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), o.config.StatementTimeout)
+	defer cancel()
+	// The driver's Stmt has no context support, so the deadline is
+	// enforced here by cancelling the raw connection running stmt.
+	return o.withRawConn(func(c *goracle.Conn) error {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				c.Cancel()
+			case <-done:
+			}
+		}()
+		return c.ExecOut(stmt, nil)
+	})
+}
+
+func (o *Oracle) ensureVersionTable() error {
+	var exists int
+	err := o.db.QueryRow("SELECT COUNT(*) FROM user_tables WHERE table_name = UPPER(:1)",
+		o.config.MigrationsTable).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("goracle/migrate: check %s: %s", o.config.MigrationsTable, err)
+	}
+	if exists > 0 {
+		return nil
+	}
+	_, err = o.db.Exec(fmt.Sprintf(
+		"CREATE TABLE %s (version NUMBER(20) NOT NULL, dirty NUMBER(1) NOT NULL)", o.config.MigrationsTable))
+	if err != nil {
+		return fmt.Errorf("goracle/migrate: create %s: %s", o.config.MigrationsTable, err)
+	}
+	return nil
+}
+
+// Version implements database.Driver.
+func (o *Oracle) Version() (version int, dirty bool, err error) {
+	var v sql.NullInt64
+	var d sql.NullInt64
+	row := o.db.QueryRow(fmt.Sprintf(
+		"SELECT version, dirty FROM %s WHERE ROWNUM = 1", o.config.MigrationsTable))
+	if err := row.Scan(&v, &d); err != nil {
+		if err == sql.ErrNoRows {
+			return database.NilVersion, false, nil
+		}
+		return 0, false, fmt.Errorf("goracle/migrate: version: %s", err)
+	}
+	return int(v.Int64), d.Int64 != 0, nil
+}
+
+// SetVersion implements database.Driver.
+func (o *Oracle) SetVersion(version int, dirty bool) error {
+	tx, err := o.db.Begin()
+	if err != nil {
+		return fmt.Errorf("goracle/migrate: SetVersion: %s", err)
+	}
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s", o.config.MigrationsTable)); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("goracle/migrate: SetVersion: delete: %s", err)
+	}
+	dirtyNum := 0
+	if dirty {
+		dirtyNum = 1
+	}
+	if _, err := tx.Exec(fmt.Sprintf("INSERT INTO %s (version, dirty) VALUES (:1, :2)",
+		o.config.MigrationsTable), version, dirtyNum); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("goracle/migrate: SetVersion: insert: %s", err)
+	}
+	return tx.Commit()
+}
+
+// Drop implements database.Driver: it drops every table in the
+// connected schema, mirroring what other golang-migrate SQL drivers do
+// for Drop.
+func (o *Oracle) Drop() error {
+	rows, err := o.db.Query("SELECT table_name FROM user_tables")
+	if err != nil {
+		return fmt.Errorf("goracle/migrate: Drop: list tables: %s", err)
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("goracle/migrate: Drop: %s", err)
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+	for _, t := range tables {
+		if _, err := o.db.Exec(fmt.Sprintf("DROP TABLE %q CASCADE CONSTRAINTS", t)); err != nil {
+			return fmt.Errorf("goracle/migrate: Drop %s: %s", t, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"database/sql"
+	"flag"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/database"
+
+	_ "github.com/vsdutka/goracle-1"
+)
+
+var fDsn = flag.String("dsn", "", "Oracle DSN")
+
+func getConnection(t *testing.T) *sql.DB {
+	flag.Parse()
+	if *fDsn == "" {
+		t.Skip("no -dsn given")
+	}
+	db, err := sql.Open("goracle", *fDsn)
+	if err != nil {
+		t.Fatalf("error connecting to %q: %s", *fDsn, err)
+	}
+	return db
+}
+
+func TestOracleDriverLifecycle(t *testing.T) {
+	db := getConnection(t)
+	defer db.Close()
+
+	driver, err := WithInstance(db, &Config{MigrationsTable: "goracle_migrate_test"})
+	if err != nil {
+		t.Fatalf("WithInstance: %s", err)
+	}
+	defer func() {
+		db.Exec("DROP TABLE goracle_migrate_test")
+	}()
+
+	var _ database.Driver = driver
+
+	if err := driver.Lock(); err != nil {
+		t.Fatalf("Lock: %s", err)
+	}
+	defer driver.Unlock()
+
+	if err := driver.SetVersion(1, false); err != nil {
+		t.Fatalf("SetVersion: %s", err)
+	}
+	version, dirty, err := driver.Version()
+	if err != nil {
+		t.Fatalf("Version: %s", err)
+	}
+	if version != 1 || dirty {
+		t.Errorf("Version()=%d,%v want 1,false", version, dirty)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import "strings"
+
+// SplitStatements splits an Oracle SQL script into individual
+// statements the way SQL*Plus does: a bare "BEGIN"/"DECLARE" line, or
+// the first line of a CREATE [OR REPLACE] PROCEDURE/FUNCTION/PACKAGE/
+// TRIGGER/TYPE BODY, starts a PL/SQL block that only ends at a line
+// containing just "/" (or at the end of the script), while ordinary SQL
+// statements end at a line ending in a semicolon, which is dropped from
+// the statement. This is needed because naively splitting on ";" breaks any
+// migration that defines a procedure, trigger or anonymous block
+// containing its own semicolons.
+func SplitStatements(script string) []string {
+	var stmts []string
+	var cur strings.Builder
+	inBlock := false
+
+	flush := func() {
+		s := strings.TrimSpace(cur.String())
+		if s != "" {
+			stmts = append(stmts, s)
+		}
+		cur.Reset()
+	}
+
+	lines := strings.Split(script, "\n")
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		upper := strings.ToUpper(trimmed)
+
+		if !inBlock && (upper == "BEGIN" || upper == "DECLARE") {
+			inBlock = true
+		}
+		if rest := strings.TrimPrefix(upper, "CREATE "); !inBlock && rest != upper {
+			rest = strings.TrimPrefix(rest, "OR REPLACE ")
+			for _, kw := range []string{"PROCEDURE ", "FUNCTION ", "PACKAGE ", "TRIGGER ", "TYPE BODY "} {
+				if strings.HasPrefix(rest, kw) {
+					inBlock = true
+				}
+			}
+		}
+
+		if inBlock {
+			if trimmed == "/" {
+				flush()
+				inBlock = false
+				continue
+			}
+			cur.WriteString(line)
+			cur.WriteByte('\n')
+			continue
+		}
+
+		if strings.HasSuffix(trimmed, ";") {
+			cur.WriteString(strings.TrimSuffix(trimmed, ";"))
+			flush()
+			continue
+		}
+		cur.WriteString(line)
+		cur.WriteByte('\n')
+	}
+	flush()
+	return stmts
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import "testing"
+
+func TestSplitStatements(t *testing.T) {
+	script := `CREATE TABLE t (id NUMBER);
+
+CREATE OR REPLACE PROCEDURE p AS
+BEGIN
+  NULL;
+END;
+/
+
+INSERT INTO t VALUES (1);
+`
+	got := SplitStatements(script)
+	if len(got) != 3 {
+		t.Fatalf("got %d statements, want 3: %#v", len(got), got)
+	}
+	if got[0] != "CREATE TABLE t (id NUMBER)" {
+		t.Errorf("stmt 0 = %q", got[0])
+	}
+	if got[2] != "INSERT INTO t VALUES (1)" {
+		t.Errorf("stmt 2 = %q", got[2])
+	}
+}
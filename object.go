@@ -0,0 +1,229 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goracle
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	"gopkg.in/goracle.v1/oracle"
+)
+
+// ObjectType describes a user-defined Oracle OBJECT, VARRAY or nested
+// table type, fetched once via OCITypeByName and cached for the
+// lifetime of the connection that looked it up.
+type ObjectType struct {
+	Owner, Name  string
+	IsCollection bool
+	// Attributes holds, for an OBJECT type, the ordered attribute names
+	// and their nested ObjectType/scalar info; for a collection type it
+	// holds a single synthetic "ELEMENT" entry carrying only that name
+	// (its DataType and Nested are left empty; the element type is not
+	// read).
+	Attributes []ObjectAttribute
+
+	tdo unsafe_tdo // opaque OCIType* handle, see lookupObjectType
+}
+
+// ObjectAttribute describes one attribute of an ObjectType.
+type ObjectAttribute struct {
+	Name     string
+	DataType string // e.g. "VARCHAR2", "NUMBER", or an ObjectType.Name for nested types
+	Nested   *ObjectType
+}
+
+// Object is a single instance of a user-defined Oracle OBJECT type.
+type Object struct {
+	typ    *ObjectType
+	values map[string]interface{}
+}
+
+// NewObject creates a zero-valued instance of typ, ready to have its
+// attributes Set and be bound as an IN/OUT parameter.
+func NewObject(typ *ObjectType) *Object {
+	return &Object{typ: typ, values: make(map[string]interface{})}
+}
+
+// Get returns the current value of the named attribute.
+func (o *Object) Get(attr string) interface{} { return o.values[attr] }
+
+// Set assigns v to the named attribute. v may itself be an *Object or
+// *Collection for nested user-defined types.
+func (o *Object) Set(attr string, v interface{}) error {
+	for _, a := range o.typ.Attributes {
+		if strings.EqualFold(a.Name, attr) {
+			o.values[attr] = v
+			return nil
+		}
+	}
+	return fmt.Errorf("goracle: %s.%s: no such attribute %q", o.typ.Owner, o.typ.Name, attr)
+}
+
+// Collection is a single instance of a user-defined Oracle VARRAY or
+// nested TABLE OF type.
+type Collection struct {
+	typ   *ObjectType
+	items []interface{}
+}
+
+// NewCollection creates an empty instance of typ (which must describe a
+// collection type).
+func NewCollection(typ *ObjectType) (*Collection, error) {
+	if !typ.IsCollection {
+		return nil, fmt.Errorf("goracle: %s.%s is not a collection type", typ.Owner, typ.Name)
+	}
+	return &Collection{typ: typ}, nil
+}
+
+// Len returns the number of elements currently in the collection.
+func (c *Collection) Len() int { return len(c.items) }
+
+// GetItem returns the i'th element (0-based).
+func (c *Collection) GetItem(i int) interface{} { return c.items[i] }
+
+// Append adds v as the new last element.
+func (c *Collection) Append(v interface{}) { c.items = append(c.items, v) }
+
+// ObjectTypeFor fetches (and caches, on this *Conn -- see its typesMu/
+// types fields) the ObjectType for owner.name on this connection, via
+// OCITypeByName. The cache is per-connection because a cached
+// ObjectType.tdo is only valid for the OCI environment/service context it
+// was resolved on.
+func (c *Conn) ObjectTypeFor(owner, name string) (*ObjectType, error) {
+	key := owner + "." + name
+	c.typesMu.Lock()
+	if t, ok := c.types[key]; ok {
+		c.typesMu.Unlock()
+		return t, nil
+	}
+	c.typesMu.Unlock()
+
+	t, err := c.lookupObjectType(owner, name)
+	if err != nil {
+		return nil, err
+	}
+	c.typesMu.Lock()
+	if c.types == nil {
+		c.types = make(map[string]*ObjectType)
+	}
+	c.types[key] = t
+	c.typesMu.Unlock()
+	return t, nil
+}
+
+// oraTagObjectPrefix marks an `ora:"OBJECT MY_SCHEMA.MY_TYPE"` struct
+// tag, parsed by objectTypeNameFromTag. No bind path calls that parser:
+// NewVariable/NewVariableByValue do not look at struct tags, and Go
+// structs or slices are not bound as SQLT_NTY automatically.
+const oraTagObjectPrefix = "OBJECT "
+
+// objectTypeNameFromTag extracts "MY_SCHEMA.MY_TYPE" out of an
+// `ora:"OBJECT MY_SCHEMA.MY_TYPE"` struct tag, or ok=false if tag isn't
+// one of ours.
+func objectTypeNameFromTag(tag reflect.StructTag) (owner, name string, ok bool) {
+	v, has := tag.Lookup("ora")
+	if !has || !strings.HasPrefix(strings.ToUpper(v), oraTagObjectPrefix) {
+		return "", "", false
+	}
+	rest := strings.TrimSpace(v[len(oraTagObjectPrefix):])
+	if dot := strings.IndexByte(rest, '.'); dot >= 0 {
+		return rest[:dot], rest[dot+1:], true
+	}
+	return "", rest, true
+}
+
+// bindObject binds v (an *Object or *Collection) to position pos of cur
+// as SQLT_NTY: it builds a fresh OCI instance of typ via
+// NewObjectInstance, fills its attributes/elements from v, and binds
+// that instance with BindObjectPos. cur must already be Prepare'd.
+func (c *Conn) bindObject(cur *oracle.Cursor, pos int, typ *ObjectType, v interface{}) error {
+	instance, err := c.buildObjectInstance(cur, typ, v)
+	if err != nil {
+		return err
+	}
+	return cur.BindObjectPos(pos, instance)
+}
+
+// buildObjectInstance allocates and populates an OCI instance of typ
+// from v (an *Object for an OBJECT type, a *Collection for a
+// VARRAY/nested table type), recursing into nested *Object attributes.
+func (c *Conn) buildObjectInstance(cur *oracle.Cursor, typ *ObjectType, v interface{}) (unsafe.Pointer, error) {
+	switch val := v.(type) {
+	case *Object:
+		instance, err := cur.NewObjectInstance(uintptr(typ.tdo))
+		if err != nil {
+			return nil, fmt.Errorf("goracle: %s.%s: %s", typ.Owner, typ.Name, err)
+		}
+		for _, attr := range typ.Attributes {
+			raw, ok := lookupAttrValue(val.values, attr.Name)
+			if !ok {
+				continue // left at its OCI default (NULL)
+			}
+			if attr.Nested != nil {
+				nestedVal, ok := raw.(*Object)
+				if !ok {
+					return nil, fmt.Errorf("goracle: %s.%s: attribute %s needs an *Object, got %T",
+						typ.Owner, typ.Name, attr.Name, raw)
+				}
+				nested, err := c.buildObjectInstance(cur, attr.Nested, nestedVal)
+				if err != nil {
+					return nil, err
+				}
+				raw = nested
+			}
+			if err := cur.SetObjectAttr(instance, uintptr(typ.tdo), attr.Name, raw); err != nil {
+				return nil, fmt.Errorf("goracle: %s.%s.%s: %s", typ.Owner, typ.Name, attr.Name, err)
+			}
+		}
+		return instance, nil
+	case *Collection:
+		instance, err := cur.NewObjectInstance(uintptr(typ.tdo))
+		if err != nil {
+			return nil, fmt.Errorf("goracle: %s.%s: %s", typ.Owner, typ.Name, err)
+		}
+		for i := 0; i < val.Len(); i++ {
+			if err := cur.AppendCollectionElem(instance, val.GetItem(i)); err != nil {
+				return nil, fmt.Errorf("goracle: %s.%s[%d]: %s", typ.Owner, typ.Name, i, err)
+			}
+		}
+		return instance, nil
+	default:
+		return nil, fmt.Errorf("goracle: bindObject: unsupported value type %T for %s.%s", v, typ.Owner, typ.Name)
+	}
+}
+
+// lookupAttrValue finds name in values case-insensitively, since Object
+// keys are whatever case callers passed to Set while attr.Name is the
+// attribute's real (OCI-reported) name.
+func lookupAttrValue(values map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := values[name]; ok {
+		return v, true
+	}
+	for k, v := range values {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// unsafe_tdo is the opaque OCIType* handle for a resolved ObjectType; it
+// is only ever produced by lookupObjectType (in object_oci.go, next to
+// the actual OCI calls) and consumed by buildObjectInstance above.
+type unsafe_tdo = uintptr
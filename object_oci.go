@@ -0,0 +1,173 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goracle
+
+/*
+#include <oci.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// lookupObjectType resolves owner.name to an ObjectType via
+// OCITypeByName, walking its attribute list (OCI_ATTR_LIST_TDO_ATTRS)
+// to populate ObjectType.Attributes. For a VARRAY/nested table it sets a
+// single "ELEMENT" attribute by name only; the collection element
+// descriptor is not read.
+func (c *Conn) lookupObjectType(owner, name string) (*ObjectType, error) {
+	cOwner := C.CString(owner)
+	defer C.free(unsafe.Pointer(cOwner))
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	var tdo unsafe.Pointer
+	rv := C.OCITypeByName(
+		unsafe.Pointer(c.envHandle()), c.errHandle(), c.svcCtx(),
+		(*C.oratext)(unsafe.Pointer(cOwner)), C.ub4(len(owner)),
+		(*C.oratext)(unsafe.Pointer(cName)), C.ub4(len(name)),
+		nil, 0, C.OCI_DURATION_SESSION, C.OCI_TYPEGET_ALL,
+		(**C.OCIType)(unsafe.Pointer(&tdo)))
+	if rv != C.OCI_SUCCESS {
+		return nil, c.oracleError(fmt.Sprintf("OCITypeByName(%s.%s)", owner, name), rv)
+	}
+
+	typ := &ObjectType{Owner: owner, Name: name, tdo: uintptr(tdo)}
+
+	var numAttrs C.ub2
+	if err := c.getTDOAttr(tdo, C.OCI_ATTR_NUM_TYPE_ATTRS, unsafe.Pointer(&numAttrs)); err != nil {
+		return nil, err
+	}
+	var isCollection C.ub1
+	if err := c.getTDOAttr(tdo, C.OCI_ATTR_IS_COLLECTION, unsafe.Pointer(&isCollection)); err != nil {
+		return nil, err
+	}
+	typ.IsCollection = isCollection != 0
+
+	if typ.IsCollection {
+		typ.Attributes = []ObjectAttribute{{Name: "ELEMENT"}}
+	} else {
+		attrs, err := c.walkTDOAttrs(tdo, numAttrs)
+		if err != nil {
+			return nil, fmt.Errorf("goracle: %s.%s: %s", owner, name, err)
+		}
+		typ.Attributes = attrs
+	}
+
+	return typ, nil
+}
+
+// getTDOAttr is a small OCIAttrGet wrapper used while walking a type
+// descriptor object's attributes.
+func (c *Conn) getTDOAttr(tdo unsafe.Pointer, attr C.ub4, dst unsafe.Pointer) error {
+	rv := C.OCIAttrGet(tdo, C.OCI_DTYPE_TYPE, dst, nil, attr, c.errHandle())
+	if rv != C.OCI_SUCCESS {
+		return c.oracleError("OCIAttrGet(TDO)", rv)
+	}
+	return nil
+}
+
+// walkTDOAttrs fetches the OCI_ATTR_LIST_TDO_ATTRS parameter list off
+// tdo and walks its numAttrs entries with OCIParamGet, building one
+// ObjectAttribute per entry. Nested OBJECT attributes (SQLT_NTY) recurse
+// into lookupObjectType so ObjectAttribute.Nested is fully populated.
+func (c *Conn) walkTDOAttrs(tdo unsafe.Pointer, numAttrs C.ub2) ([]ObjectAttribute, error) {
+	var listParam unsafe.Pointer
+	rv := C.OCIAttrGet(tdo, C.OCI_DTYPE_TYPE, unsafe.Pointer(&listParam), nil, C.OCI_ATTR_LIST_TDO_ATTRS, c.errHandle())
+	if rv != C.OCI_SUCCESS {
+		return nil, c.oracleError("OCIAttrGet(OCI_ATTR_LIST_TDO_ATTRS)", rv)
+	}
+
+	attrs := make([]ObjectAttribute, 0, int(numAttrs))
+	for pos := C.ub4(1); pos <= C.ub4(numAttrs); pos++ {
+		var attrParam unsafe.Pointer
+		rv := C.OCIParamGet(listParam, C.OCI_DTYPE_PARAM, c.errHandle(), &attrParam, pos)
+		if rv != C.OCI_SUCCESS {
+			return nil, c.oracleError(fmt.Sprintf("OCIParamGet(attr %d)", pos), rv)
+		}
+
+		var namep unsafe.Pointer
+		var namelen C.ub4
+		if rv := C.OCIAttrGet(attrParam, C.OCI_DTYPE_PARAM, unsafe.Pointer(&namep), &namelen, C.OCI_ATTR_NAME, c.errHandle()); rv != C.OCI_SUCCESS {
+			return nil, c.oracleError("OCIAttrGet(OCI_ATTR_NAME)", rv)
+		}
+
+		var dataType C.ub2
+		if rv := C.OCIAttrGet(attrParam, C.OCI_DTYPE_PARAM, unsafe.Pointer(&dataType), nil, C.OCI_ATTR_DATA_TYPE, c.errHandle()); rv != C.OCI_SUCCESS {
+			return nil, c.oracleError("OCIAttrGet(OCI_ATTR_DATA_TYPE)", rv)
+		}
+
+		attr := ObjectAttribute{
+			Name:     C.GoStringN((*C.char)(namep), C.int(namelen)),
+			DataType: sqlTypeName(dataType),
+		}
+
+		if dataType == C.SQLT_NTY {
+			schema, typeName, err := c.attrTypeName(attrParam)
+			if err != nil {
+				return nil, err
+			}
+			nested, err := c.lookupObjectType(schema, typeName)
+			if err != nil {
+				return nil, err
+			}
+			attr.DataType = nested.Name
+			attr.Nested = nested
+		}
+
+		attrs = append(attrs, attr)
+	}
+	return attrs, nil
+}
+
+// attrTypeName reads OCI_ATTR_SCHEMA_NAME/OCI_ATTR_TYPE_NAME off a
+// SQLT_NTY attribute's parameter descriptor, identifying the nested
+// ObjectType to resolve.
+func (c *Conn) attrTypeName(attrParam unsafe.Pointer) (schema, typeName string, err error) {
+	var schemap unsafe.Pointer
+	var schemalen C.ub4
+	if rv := C.OCIAttrGet(attrParam, C.OCI_DTYPE_PARAM, unsafe.Pointer(&schemap), &schemalen, C.OCI_ATTR_SCHEMA_NAME, c.errHandle()); rv != C.OCI_SUCCESS {
+		return "", "", c.oracleError("OCIAttrGet(OCI_ATTR_SCHEMA_NAME)", rv)
+	}
+	var namep unsafe.Pointer
+	var namelen C.ub4
+	if rv := C.OCIAttrGet(attrParam, C.OCI_DTYPE_PARAM, unsafe.Pointer(&namep), &namelen, C.OCI_ATTR_TYPE_NAME, c.errHandle()); rv != C.OCI_SUCCESS {
+		return "", "", c.oracleError("OCIAttrGet(OCI_ATTR_TYPE_NAME)", rv)
+	}
+	return C.GoStringN((*C.char)(schemap), C.int(schemalen)), C.GoStringN((*C.char)(namep), C.int(namelen)), nil
+}
+
+// sqlTypeName maps an OCI_ATTR_DATA_TYPE code to the scalar type name
+// goracle reports in ObjectAttribute.DataType. SQLT_NTY is handled
+// separately by the caller, which overwrites DataType with the nested
+// type's own name.
+func sqlTypeName(dataType C.ub2) string {
+	switch dataType {
+	case C.SQLT_CHR, C.SQLT_AFC:
+		return "VARCHAR2"
+	case C.SQLT_NUM:
+		return "NUMBER"
+	case C.SQLT_DAT, C.SQLT_TIMESTAMP:
+		return "DATE"
+	case C.SQLT_NTY:
+		return "OBJECT"
+	default:
+		return fmt.Sprintf("SQLT(%d)", int(dataType))
+	}
+}
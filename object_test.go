@@ -0,0 +1,98 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goracle
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestObjectTypeRoundTrip(t *testing.T) {
+	db := getConnection(t)
+
+	if _, err := db.Exec(`CREATE OR REPLACE TYPE goracle_obj_test AS OBJECT (id NUMBER, name VARCHAR2(30))`); err != nil {
+		t.Skip("cannot create scratch type (needs CREATE TYPE privilege): ", err)
+	}
+	defer db.Exec("DROP TYPE goracle_obj_test")
+
+	sqlConn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlConn.Close()
+
+	if err := sqlConn.Raw(func(raw interface{}) error {
+		c, ok := raw.(*Conn)
+		if !ok {
+			t.Skip("driver connection does not support ObjectTypeFor")
+		}
+		typ, err := c.ObjectTypeFor("", "GORACLE_OBJ_TEST")
+		if err != nil {
+			return err
+		}
+		if typ.IsCollection {
+			t.Error("GORACLE_OBJ_TEST is an OBJECT type, not a collection")
+		}
+		if len(typ.Attributes) != 2 {
+			t.Fatalf("Attributes = %+v, want 2 entries (ID, NAME)", typ.Attributes)
+		}
+		if !strings.EqualFold(typ.Attributes[0].Name, "ID") || typ.Attributes[0].DataType != "NUMBER" {
+			t.Errorf("Attributes[0] = %+v, want ID/NUMBER", typ.Attributes[0])
+		}
+		if !strings.EqualFold(typ.Attributes[1].Name, "NAME") || typ.Attributes[1].DataType != "VARCHAR2" {
+			t.Errorf("Attributes[1] = %+v, want NAME/VARCHAR2", typ.Attributes[1])
+		}
+
+		obj := NewObject(typ)
+		if err := obj.Set("no_such_attr", 1); err == nil {
+			t.Error("expected an error setting an unknown attribute")
+		}
+		if err := obj.Set("name", "hi"); err != nil {
+			t.Errorf("Set(name): %s", err)
+		}
+		if got := obj.Get("name"); got != "hi" {
+			t.Errorf("Get(name) = %v, want %q", got, "hi")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("ObjectTypeFor: %s", err)
+	}
+}
+
+func TestCollectionBasics(t *testing.T) {
+	typ := &ObjectType{Owner: "SCOTT", Name: "NUM_TAB", IsCollection: true,
+		Attributes: []ObjectAttribute{{Name: "ELEMENT", DataType: "NUMBER"}}}
+
+	c, err := NewCollection(typ)
+	if err != nil {
+		t.Fatalf("NewCollection: %s", err)
+	}
+	c.Append(1)
+	c.Append(2)
+	if c.Len() != 2 {
+		t.Fatalf("Len()=%d, want 2", c.Len())
+	}
+	if c.GetItem(1) != 2 {
+		t.Errorf("GetItem(1)=%v, want 2", c.GetItem(1))
+	}
+
+	nonColl := &ObjectType{Owner: "SCOTT", Name: "NOT_A_COLL"}
+	if _, err := NewCollection(nonColl); err == nil {
+		t.Error("expected an error for a non-collection ObjectType")
+	}
+}
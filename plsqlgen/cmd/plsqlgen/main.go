@@ -0,0 +1,108 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command plsqlgen introspects an Oracle PL/SQL package through a
+// goracle connection and emits a typed Go wrapper for it.
+//
+//	plsqlgen -dsn user/pwd@db -package MY_PKG -out gen.go
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	_ "github.com/vsdutka/goracle-1"
+	"github.com/vsdutka/goracle-1/plsqlgen"
+)
+
+func main() {
+	var (
+		dsn      = flag.String("dsn", "", "Oracle DSN, as accepted by sql.Open(\"goracle\", dsn)")
+		owner    = flag.String("owner", "", "schema owning the package (defaults to the connected user)")
+		pkgName  = flag.String("package", "", "PL/SQL package name to introspect")
+		goPkg    = flag.String("gopackage", "main", "Go package name for the generated file")
+		out      = flag.String("out", "", "output file (defaults to stdout)")
+		rename   = flag.String("rename", "", "comma-separated ORACLE_NAME=GoName overrides")
+		nullable = flag.Bool("nullable", false, "generate sql.Null* types for nullable arguments")
+		tests    = flag.Bool("tests", false, "also emit a _test.go scaffold next to -out")
+	)
+	flag.Parse()
+
+	if *dsn == "" || *pkgName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := sql.Open("goracle", *dsn)
+	if err != nil {
+		log.Fatalf("open %q: %s", *dsn, err)
+	}
+	defer db.Close()
+
+	pkg, err := plsqlgen.Introspect(db, *owner, *pkgName)
+	if err != nil {
+		log.Fatalf("introspect %s: %s", *pkgName, err)
+	}
+
+	opts := plsqlgen.Options{
+		Package:  *goPkg,
+		Rename:   parseRename(*rename),
+		Nullable: *nullable,
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("create %q: %s", *out, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := plsqlgen.Generate(w, pkg, opts); err != nil {
+		log.Fatalf("generate %s: %s", *pkgName, err)
+	}
+
+	if *tests && *out != "" {
+		tf, err := os.Create(strings.TrimSuffix(*out, ".go") + "_test.go")
+		if err != nil {
+			log.Fatalf("create test scaffold: %s", err)
+		}
+		defer tf.Close()
+		if err := plsqlgen.GenerateTests(tf, pkg, opts); err != nil {
+			log.Fatalf("generate tests for %s: %s", *pkgName, err)
+		}
+	}
+}
+
+func parseRename(s string) map[string]string {
+	if s == "" {
+		return nil
+	}
+	m := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		m[strings.ToUpper(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+	}
+	return m
+}
@@ -0,0 +1,31 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plsqlgen introspects an Oracle schema's PL/SQL package
+// definitions (ALL_ARGUMENTS only; ALL_PROCEDURES, ALL_TYPES and
+// ALL_PLSQL_TYPES are not read) and generates typed Go wrapper functions
+// for every procedure and function it finds, so callers don't have to
+// hand-write oracle bind ceremony for every call.
+//
+// The generated code builds on database/sql with the goracle driver:
+// each wrapper runs a statement of the form
+// "BEGIN pkg.proc(:arg1,:arg2,...); END;" with one sql.Named bind per
+// argument, wrapping OUT and IN/OUT arguments in sql.Out. A REF CURSOR
+// argument is handed back as the *sql.Rows of the call; no typed row
+// structs are generated.
+//
+// See cmd/plsqlgen for the command-line entry point.
+package plsqlgen
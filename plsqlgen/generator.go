@@ -0,0 +1,244 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plsqlgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// typeMap maps Oracle scalar ALL_ARGUMENTS.DATA_TYPE values to the Go
+// type used for a non-nullable bind/scan. Nullable callers get the
+// matching sql.Null* type instead, where one exists (see goType).
+var typeMap = map[string]string{
+	"VARCHAR2":       "string",
+	"CHAR":           "string",
+	"NUMBER":         "float64",
+	"PLS_INTEGER":    "int64",
+	"BINARY_INTEGER": "int64",
+	"DATE":           "time.Time",
+	"TIMESTAMP":      "time.Time",
+	"BOOLEAN":        "bool",
+	"REF CURSOR":     "*sql.Rows",
+	"PL/SQL TABLE":   "[]interface{}",
+	"PL/SQL RECORD":  "interface{}",
+}
+
+// Options controls how Generate renames identifiers and picks nullable
+// types; it's the in-process form of the plsqlgen command's flags.
+type Options struct {
+	// Package is the Go package name for the generated file.
+	Package string
+	// Rename maps an upper-case Oracle procedure or argument name to the
+	// Go identifier that should be used for it, for callers who don't
+	// like the mechanical CamelCase conversion. The Oracle name is
+	// upper-cased before the lookup, so keys with lower-case letters
+	// never match.
+	Rename map[string]string
+	// Nullable, when true, generates sql.NullString/NullFloat64/...
+	// arguments for any parameter ALL_ARGUMENTS reports as nullable,
+	// instead of failing fast on NULL.
+	Nullable bool
+	// EmitTests is not read by Generate or GenerateTests: Generate never
+	// writes test scaffolding, and GenerateTests renders it whenever it is
+	// called. The plsqlgen command decides with its own -tests flag.
+	EmitTests bool
+}
+
+// Generate renders the Go source for pkg's wrapper functions according
+// to opts and writes it to w, gofmt'd. It does not write test scaffolding
+// even if opts.EmitTests is set; call GenerateTests for that, so callers
+// writing to a single io.Writer can interleave them as they see fit.
+func Generate(w io.Writer, pkg *Package, opts Options) error {
+	for i := range pkg.Procedures {
+		resolveNames(&pkg.Procedures[i], opts)
+	}
+	var buf bytes.Buffer
+	if err := sourceTmpl.Execute(&buf, struct {
+		Package string
+		Pkg     *Package
+	}{opts.Package, pkg}); err != nil {
+		return fmt.Errorf("render %s.%s: %s", pkg.Schema, pkg.Name, err)
+	}
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		// Still return the unformatted source so the caller can inspect
+		// what went wrong, mirroring how go/format errors are normally
+		// surfaced by code generators.
+		_, _ = w.Write(buf.Bytes())
+		return fmt.Errorf("gofmt %s.%s: %s", pkg.Schema, pkg.Name, err)
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// GenerateTests renders a _test.go scaffold for pkg: one skipped test
+// per generated wrapper function, ready for a developer to fill in
+// fixture data, in the same style as the hand-written tests it replaces.
+func GenerateTests(w io.Writer, pkg *Package, opts Options) error {
+	var buf bytes.Buffer
+	if err := testTmpl.Execute(&buf, struct {
+		Package string
+		Pkg     *Package
+	}{opts.Package, pkg}); err != nil {
+		return fmt.Errorf("render tests for %s.%s: %s", pkg.Schema, pkg.Name, err)
+	}
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		_, _ = w.Write(buf.Bytes())
+		return fmt.Errorf("gofmt tests for %s.%s: %s", pkg.Schema, pkg.Name, err)
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+func resolveNames(p *Procedure, opts Options) {
+	p.GoName = renamedOr(p.Name, opts)
+	if p.OverloadID != "" && p.OverloadID != "0" {
+		p.GoName += "V" + p.OverloadID
+	}
+	for i := range p.Args {
+		a := &p.Args[i]
+		if a.IsReturn() {
+			a.GoName = "result"
+		} else {
+			a.GoName = lowerFirst(renamedOr(a.Name, opts))
+		}
+		a.GoType = goType(*a, opts)
+	}
+}
+
+func renamedOr(name string, opts Options) string {
+	if opts.Rename != nil {
+		if renamed, ok := opts.Rename[strings.ToUpper(name)]; ok {
+			return renamed
+		}
+	}
+	return exportedName(name)
+}
+
+func goType(a Argument, opts Options) string {
+	t, ok := typeMap[strings.ToUpper(a.DataType)]
+	if !ok {
+		t = "interface{}"
+	}
+	if opts.Nullable && a.Nullable {
+		switch t {
+		case "string":
+			return "sql.NullString"
+		case "float64":
+			return "sql.NullFloat64"
+		case "int64":
+			return "sql.NullInt64"
+		case "bool":
+			return "sql.NullBool"
+		}
+	}
+	if a.Direction == DirOut || a.Direction == DirInOut {
+		return "*" + t
+	}
+	return t
+}
+
+func exportedName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool { return r == '_' })
+	var sb strings.Builder
+	for _, p := range parts {
+		if p == "" {
+			continue
+		}
+		sb.WriteString(strings.ToUpper(p[:1]))
+		sb.WriteString(strings.ToLower(p[1:]))
+	}
+	if sb.Len() == 0 {
+		return "X"
+	}
+	return sb.String()
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// bindExpr renders the sql.Named(...) argument generator passes to
+// Exec/QueryContext for a. OUT and IN/OUT arguments are wrapped in
+// sql.Out so the driver actually writes the result back through the
+// pointer the caller supplied, instead of silently treating them as IN.
+func bindExpr(a Argument) string {
+	if a.Direction == DirOut || a.Direction == DirInOut {
+		return fmt.Sprintf("sql.Named(%q, sql.Out{Dest: %s, In: %t})", a.GoName, a.GoName, a.Direction == DirInOut)
+	}
+	return fmt.Sprintf("sql.Named(%q, %s)", a.GoName, a.GoName)
+}
+
+var sourceTmpl = template.Must(template.New("source").Funcs(template.FuncMap{
+	"bindExpr": bindExpr,
+}).Parse(`// Code generated by plsqlgen from {{.Pkg.Schema}}.{{.Pkg.Name}}; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// {{.Pkg.GoName}} wraps the PL/SQL package {{.Pkg.Schema}}.{{.Pkg.Name}}.
+type {{.Pkg.GoName}} struct{}
+
+{{range .Pkg.Procedures}}
+// {{.GoName}} calls {{$.Pkg.Schema}}.{{$.Pkg.Name}}.{{.Name}}.
+func ({{$.Pkg.GoName}}) {{.GoName}}(ctx context.Context, db *sql.DB{{range .Args}}{{if not .IsReturn}}, {{.GoName}} {{.GoType}}{{end}}{{end}}) error {
+{{if .CursorArg}}	// {{with .CursorArg}}{{.GoName}}{{end}} is a REF CURSOR: its rows only
+	// ever come back as the statement's own result set, so this runs
+	// through QueryContext rather than ExecContext, and is populated from
+	// the returned *sql.Rows once the call succeeds.
+	rows, err := db.QueryContext(ctx, "BEGIN {{$.Pkg.Name}}.{{.Name}}({{range $i, $a := .Args}}{{if not $a.IsReturn}}{{if $i}},{{end}}:{{$a.GoName}}{{end}}{{end}}); END;"{{range .Args}}{{if not .IsReturn}}, {{bindExpr .}}{{end}}{{end}})
+	if err != nil {
+		return fmt.Errorf("{{.Name}}: %s", err)
+	}
+	{{with .CursorArg}}*{{.GoName}} = rows{{end}}
+	return nil
+{{else}}	_, err := db.ExecContext(ctx, "BEGIN {{$.Pkg.Name}}.{{.Name}}({{range $i, $a := .Args}}{{if not $a.IsReturn}}{{if $i}},{{end}}:{{$a.GoName}}{{end}}{{end}}); END;"{{range .Args}}{{if not .IsReturn}}, {{bindExpr .}}{{end}}{{end}})
+	if err != nil {
+		return fmt.Errorf("{{.Name}}: %s", err)
+	}
+	return nil
+{{end}}}
+{{end}}
+`))
+
+var testTmpl = template.Must(template.New("test").Parse(`// Code generated by plsqlgen from {{.Pkg.Schema}}.{{.Pkg.Name}}; DO NOT EDIT.
+
+package {{.Package}}
+
+import "testing"
+
+{{range .Pkg.Procedures}}
+func Test{{$.Pkg.GoName}}_{{.GoName}}(t *testing.T) {
+	t.Skip("fill in fixture data for {{$.Pkg.Name}}.{{.Name}} and remove this Skip")
+}
+{{end}}
+`))
@@ -0,0 +1,104 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plsqlgen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExportedName(t *testing.T) {
+	for i, tst := range []struct{ in, want string }{
+		{"get_employee", "GetEmployee"},
+		{"MY_PKG", "MyPkg"},
+		{"x", "X"},
+		{"", "X"},
+	} {
+		if got := exportedName(tst.in); got != tst.want {
+			t.Errorf("%d. exportedName(%q)=%q, want %q", i, tst.in, got, tst.want)
+		}
+	}
+}
+
+func TestGoType(t *testing.T) {
+	for i, tst := range []struct {
+		arg  Argument
+		opts Options
+		want string
+	}{
+		{Argument{DataType: "VARCHAR2", Direction: DirIn}, Options{}, "string"},
+		{Argument{DataType: "VARCHAR2", Direction: DirOut}, Options{}, "*string"},
+		{Argument{DataType: "NUMBER", Direction: DirIn, Nullable: true}, Options{Nullable: true}, "sql.NullFloat64"},
+		{Argument{DataType: "FOOBAR", Direction: DirIn}, Options{}, "interface{}"},
+	} {
+		if got := goType(tst.arg, tst.opts); got != tst.want {
+			t.Errorf("%d. goType(%+v)=%q, want %q", i, tst.arg, got, tst.want)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	pkg := &Package{
+		Schema: "SCOTT",
+		Name:   "EMP_PKG",
+		GoName: "EmpPkg",
+		Procedures: []Procedure{
+			{Name: "RAISE_SALARY", Args: []Argument{
+				{Name: "EMPNO", Position: 1, Direction: DirIn, DataType: "NUMBER"},
+				{Name: "PCT", Position: 2, Direction: DirIn, DataType: "NUMBER"},
+			}},
+		},
+	}
+	var buf bytes.Buffer
+	if err := Generate(&buf, pkg, Options{Package: "empwrap"}); err != nil {
+		t.Fatalf("Generate: %s", err)
+	}
+	if buf.Len() == 0 {
+		t.Fatal("Generate produced no output")
+	}
+}
+
+func TestGenerateOutInOutCursor(t *testing.T) {
+	pkg := &Package{
+		Schema: "SCOTT",
+		Name:   "EMP_PKG",
+		GoName: "EmpPkg",
+		Procedures: []Procedure{
+			{Name: "FIND_REPORTS", Args: []Argument{
+				{Name: "MGR", Position: 1, Direction: DirIn, DataType: "NUMBER"},
+				{Name: "COUNT", Position: 2, Direction: DirInOut, DataType: "PLS_INTEGER"},
+				{Name: "CUR", Position: 3, Direction: DirOut, DataType: "REF CURSOR"},
+			}},
+		},
+	}
+	var buf bytes.Buffer
+	if err := Generate(&buf, pkg, Options{Package: "empwrap"}); err != nil {
+		t.Fatalf("Generate: %s", err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"sql.Out{Dest: count, In: true}",
+		"sql.Out{Dest: cur, In: false}",
+		"db.QueryContext(ctx,",
+		"*cur = rows",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("generated source missing %q:\n%s", want, got)
+		}
+	}
+}
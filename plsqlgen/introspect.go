@@ -0,0 +1,93 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plsqlgen
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+const qryArguments = `SELECT object_name, overload, argument_name, position,
+       in_out, data_type, type_owner, type_name, type_subname, nullable
+  FROM all_arguments
+ WHERE owner = NVL(:1, USER)
+   AND package_name = :2
+ ORDER BY object_name, overload, position`
+
+// Introspect reads ALL_ARGUMENTS for the named package and returns a
+// Package describing every procedure and function listed there. An empty
+// owner selects the connected user's schema. ALL_PROCEDURES, ALL_TYPES
+// and ALL_PLSQL_TYPES are not read, so a user-defined argument type is
+// described only by its ALL_ARGUMENTS DATA_TYPE and TYPE_* columns.
+func Introspect(db *sql.DB, owner, pkg string) (*Package, error) {
+	rows, err := db.Query(qryArguments, owner, pkg)
+	if err != nil {
+		return nil, fmt.Errorf("introspect %s.%s: %s", owner, pkg, err)
+	}
+	defer rows.Close()
+
+	procs := make(map[string]*Procedure)
+	var order []string
+	for rows.Next() {
+		var (
+			objectName, overload             sql.NullString
+			argName, inOut, dataType         sql.NullString
+			typeOwner, typeName, typeSubName sql.NullString
+			position                         sql.NullInt64
+			nullable                         sql.NullString
+		)
+		if err := rows.Scan(&objectName, &overload, &argName, &position,
+			&inOut, &dataType, &typeOwner, &typeName, &typeSubName, &nullable); err != nil {
+			return nil, fmt.Errorf("introspect %s.%s: scan: %s", owner, pkg, err)
+		}
+		key := objectName.String + "#" + overload.String
+		p, ok := procs[key]
+		if !ok {
+			p = &Procedure{Package: pkg, Name: objectName.String, OverloadID: overload.String}
+			procs[key] = p
+			order = append(order, key)
+		}
+		p.Args = append(p.Args, Argument{
+			Name:        argName.String,
+			Position:    int(position.Int64),
+			Direction:   Direction(inOut.String),
+			DataType:    dataType.String,
+			TypeOwner:   typeOwner.String,
+			TypeName:    typeName.String,
+			TypeSubName: typeSubName.String,
+			Nullable:    nullable.String == "Y",
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("introspect %s.%s: %s", owner, pkg, err)
+	}
+
+	sort.Strings(order)
+	result := &Package{Schema: owner, Name: pkg, GoName: exportedName(pkg)}
+	for _, key := range order {
+		p := procs[key]
+		for _, a := range p.Args {
+			if a.IsReturn() {
+				p.IsFunction = true
+				break
+			}
+		}
+		result.Procedures = append(result.Procedures, *p)
+	}
+	return result, nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plsqlgen
+
+import "strings"
+
+// Direction is the PL/SQL parameter passing mode, as reported in
+// ALL_ARGUMENTS.IN_OUT.
+type Direction string
+
+const (
+	DirIn    Direction = "IN"
+	DirOut   Direction = "OUT"
+	DirInOut Direction = "IN/OUT"
+)
+
+// Argument describes a single formal parameter (or the return value, for
+// functions) of a PL/SQL procedure or function, as read from
+// ALL_ARGUMENTS.
+type Argument struct {
+	Name        string
+	Position    int
+	Direction   Direction
+	DataType    string // ALL_ARGUMENTS.DATA_TYPE, e.g. VARCHAR2, NUMBER, PL/SQL TABLE
+	TypeOwner   string
+	TypeName    string
+	TypeSubName string
+	Nullable    bool
+
+	// GoName and GoType are filled in by the generator after resolving
+	// DataType against the type map and any user-supplied rename tags.
+	GoName string
+	GoType string
+}
+
+// IsReturn reports whether this argument is the return value of a
+// function (ALL_ARGUMENTS reports it with ARGUMENT_NAME IS NULL and
+// POSITION=0).
+func (a Argument) IsReturn() bool {
+	return a.Name == "" && a.Position == 0
+}
+
+// Procedure describes one overload of a PL/SQL procedure or function
+// inside a package, with its arguments in call order.
+type Procedure struct {
+	Package    string
+	Name       string
+	OverloadID string
+	IsFunction bool
+	Args       []Argument
+
+	// GoName is the exported Go function name generated for this
+	// overload (Procedure.Name, tag-renamed and overload-suffixed if
+	// OverloadID is non-empty and not "0").
+	GoName string
+}
+
+// CursorArg returns the argument that carries a REF CURSOR result, if
+// any. Generate gives such a procedure a QueryContext-based body instead
+// of the usual ExecContext one, since a REF CURSOR's rows only ever come
+// back as the statement's own result set, never through an OUT bind.
+func (p Procedure) CursorArg() *Argument {
+	for i := range p.Args {
+		if strings.EqualFold(p.Args[i].DataType, "REF CURSOR") {
+			return &p.Args[i]
+		}
+	}
+	return nil
+}
+
+// Package is a fully introspected PL/SQL package, ready to be rendered
+// by Generate.
+type Package struct {
+	Schema     string
+	Name       string
+	GoName     string
+	Procedures []Procedure
+}
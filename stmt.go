@@ -0,0 +1,75 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goracle
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+)
+
+// Stmt implements driver.Stmt. Exec runs through oracle.Cursor.Execute,
+// the same path registerQuery/ExecOut use elsewhere in this driver.
+// Query always fails: the vendored oracle.Cursor has no row-fetch path
+// (no OCIStmtFetch/OCIDefineByPos anywhere in this tree, see
+// vendor/gopkg.in/goracle.v1/oracle/cursor.go), so there is no way to
+// hand back a driver.Rows. ExecOut does not fill that gap either: its
+// binds cover int64 and string values (and pointers to them) only, so a
+// REF CURSOR cannot be bound there.
+type Stmt struct {
+	conn  *Conn
+	query string
+}
+
+var _ driver.Stmt = (*Stmt)(nil)
+
+// Prepare implements driver.Conn.
+func (c *Conn) Prepare(query string) (driver.Stmt, error) {
+	return &Stmt{conn: c, query: query}, nil
+}
+
+// Close is a no-op: the query text isn't parsed until Exec/Query runs,
+// so there is no server-side resource to release early.
+func (s *Stmt) Close() error { return nil }
+
+// NumInput reports that the driver doesn't pre-validate argument counts;
+// a mismatch surfaces as a real Oracle error out of Exec instead.
+func (s *Stmt) NumInput() int { return -1 }
+
+// Exec implements driver.Stmt. Positional args are bound as "1", "2",
+// ..., the same convention oracle.Cursor.Execute uses throughout this
+// driver (see registerQuery, ExecMany).
+func (s *Stmt) Exec(args []driver.Value) (driver.Result, error) {
+	cur := s.conn.Connection.NewCursor()
+	defer cur.Close()
+	params := make(map[string]interface{}, len(args))
+	for i, a := range args {
+		params[strconv.Itoa(i+1)] = a
+	}
+	if err := cur.Execute(s.query, params); err != nil {
+		return nil, fmt.Errorf("goracle: Exec: %s", err)
+	}
+	// oracle.Cursor reports only success/failure, never a row count, so
+	// there is nothing honest to put in RowsAffected beyond 0.
+	return driver.RowsAffected(0), nil
+}
+
+// Query implements driver.Stmt but always fails; see the Stmt doc
+// comment for why result sets aren't reachable this way.
+func (s *Stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, fmt.Errorf("goracle: Query: result sets are not supported by this driver")
+}
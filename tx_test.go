@@ -0,0 +1,142 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goracle
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestBeginTxSerializableReadOnly(t *testing.T) {
+	db := getConnection(t)
+
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		t.Fatalf("BeginTx: %s", err)
+	}
+	defer tx.Rollback()
+
+	var dual string
+	if err := tx.QueryRow("SELECT 'x' FROM DUAL").Scan(&dual); err != nil {
+		t.Errorf("query in tx: %s", err)
+	}
+}
+
+func TestBeginTxUnsupportedIsolation(t *testing.T) {
+	db := getConnection(t)
+
+	_, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	if err == nil {
+		t.Error("expected an error for an isolation level Oracle doesn't support")
+	}
+}
+
+func TestSavepoint(t *testing.T) {
+	db := getConnection(t)
+
+	if _, err := db.Exec("CREATE TABLE goracle_savepoint_test (id NUMBER)"); err != nil {
+		t.Skip("cannot create scratch table: ", err)
+	}
+	defer db.Exec("DROP TABLE goracle_savepoint_test")
+
+	// Savepoint/RollbackTo only mean anything within a single transaction
+	// on a single connection, so this pins one connection (sqlConn) and
+	// runs everything on it: the inserts through the *sql.Tx started on
+	// it, the raw Savepoint/RollbackTo calls through sqlConn.Raw; BeginTx
+	// is what turns off the connection's autocommit for the duration.
+	sqlConn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlConn.Close()
+
+	tx, err := sqlConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("BeginTx: %s", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO goracle_savepoint_test VALUES (1)"); err != nil {
+		t.Fatalf("insert 1: %s", err)
+	}
+
+	if err := sqlConn.Raw(func(raw interface{}) error {
+		c, ok := raw.(*Conn)
+		if !ok {
+			t.Skip("driver connection does not support Savepoint")
+		}
+		return c.Savepoint("sp1")
+	}); err != nil {
+		t.Fatalf("Savepoint: %s", err)
+	}
+
+	if _, err := tx.Exec("INSERT INTO goracle_savepoint_test VALUES (2)"); err != nil {
+		t.Fatalf("insert 2: %s", err)
+	}
+
+	if err := sqlConn.Raw(func(raw interface{}) error {
+		c, ok := raw.(*Conn)
+		if !ok {
+			t.Skip("driver connection does not support RollbackTo")
+		}
+		return c.RollbackTo("sp1")
+	}); err != nil {
+		t.Fatalf("RollbackTo: %s", err)
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM goracle_savepoint_test").Scan(&count); err != nil {
+		t.Fatalf("count: %s", err)
+	}
+	if count != 1 {
+		t.Errorf("count=%d after RollbackTo, want 1", count)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %s", err)
+	}
+}
+
+func TestSavepointRejectsInvalidName(t *testing.T) {
+	db := getConnection(t)
+
+	sqlConn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlConn.Close()
+
+	if err := sqlConn.Raw(func(raw interface{}) error {
+		c, ok := raw.(*Conn)
+		if !ok {
+			t.Skip("driver connection does not support Savepoint")
+		}
+		if err := c.Savepoint("sp1; DROP TABLE users"); err == nil {
+			t.Error("expected an error for a savepoint name with invalid characters")
+		}
+		if err := c.RollbackTo("sp1' --"); err == nil {
+			t.Error("expected an error for a rollback-to name with invalid characters")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
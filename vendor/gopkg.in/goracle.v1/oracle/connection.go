@@ -0,0 +1,128 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package oracle is goracle's own minimal OCI binding layer. It lives at
+// this import path, and reuses some names and call shapes (NewConnection,
+// Cursor.Execute, Cancel, Commit, ...) from gopkg.in/goracle.v1/oracle for
+// familiarity, but it is NOT a vendored or forked copy of that module: the
+// real upstream package (see gopkg.in/goracle.v1@v1.0.0-20160215060755-
+// 9d7b6d9aec72/oracle) is a ~9000-line binding with its own Environment/
+// session-pool/variable machinery that this package doesn't implement.
+// This is a from-scratch, much narrower implementation covering only
+// what the goracle driver on top of it needs (connect, simple
+// Execute/ExecuteMany, OUT binds, object/collection binds); it has no
+// row-fetch (SELECT) support at all, unlike the real upstream package.
+package oracle
+
+import "unsafe"
+
+// Connection is a single OCI session. Most of its fields mirror
+// upstream and stay unexported; env/srv/svc/errh are the underlying OCI
+// handles, reached from outside this package only through the
+// EnvHandle/SvcCtxHandle/ErrorHandle accessors added below.
+type Connection struct {
+	username, password, dsn string
+	autocommit              bool
+
+	wallet WalletConfig
+
+	env, srv, svc, errh unsafe.Pointer
+}
+
+// WalletConfig carries the mutual-TLS (TCPS) attributes goracle adds on
+// top of upstream's plain-TCP-only Connection: wallet location/password
+// for certificate-based auth, and the DN goracle should match the
+// server's certificate against. It has no effect unless TCPS is true.
+type WalletConfig struct {
+	TCPS            bool
+	Wallet          string
+	WalletPassword  string
+	SSLServerCertDN string
+}
+
+// NewConnection opens a new OCI session for username/password@dsn. dsn
+// is an Oracle connect string (EZCONNECT or a full connect descriptor).
+// When autocommit is true, every Cursor.Execute commits immediately.
+func NewConnection(username, password, dsn string, autocommit bool) (*Connection, error) {
+	return NewConnectionWithWallet(username, password, dsn, autocommit, WalletConfig{})
+}
+
+// NewConnectionWithWallet is NewConnection plus wallet/TCPS attributes,
+// goracle's addition on top of the vendored upstream API for mutual-TLS
+// connections. The extra attributes are set on the server handle before
+// OCIServerAttach, the same place OCI itself expects SSL configuration.
+func NewConnectionWithWallet(username, password, dsn string, autocommit bool, wallet WalletConfig) (*Connection, error) {
+	c := &Connection{username: username, password: password, dsn: dsn, autocommit: autocommit, wallet: wallet}
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close ends the OCI session and releases its handles.
+func (c *Connection) Close() error {
+	return c.disconnect()
+}
+
+// Ping reports whether the session is still usable.
+func (c *Connection) Ping() error {
+	cur := c.NewCursor()
+	defer cur.Close()
+	return cur.Execute("SELECT 1 FROM DUAL", nil)
+}
+
+// Cancel aborts whatever call is currently in flight on this connection
+// (OCIBreak), and resets the connection back to a usable state
+// (OCIReset) so it can be reused afterwards.
+func (c *Connection) Cancel() error {
+	return c.breakAndReset()
+}
+
+// Commit commits the current transaction.
+func (c *Connection) Commit() error {
+	return c.commit()
+}
+
+// Rollback rolls back the current transaction.
+func (c *Connection) Rollback() error {
+	return c.rollback()
+}
+
+// NewCursor returns a new Cursor (OCI statement handle) on this
+// connection.
+func (c *Connection) NewCursor() *Cursor {
+	return &Cursor{conn: c}
+}
+
+// SetAutoCommit changes whether Cursor.Execute/ExecuteMany commit
+// immediately on success. (*goracle.Conn).BeginTx turns this off for the
+// lifetime of the driver.Tx it returns -- otherwise every statement run
+// under a transaction would commit (and end that transaction) the moment
+// it succeeded, making Commit/Rollback/Savepoint/RollbackTo no-ops on top
+// of work that was already permanent.
+func (c *Connection) SetAutoCommit(autocommit bool) {
+	c.autocommit = autocommit
+}
+
+// EnvHandle, SvcCtxHandle and ErrorHandle expose this connection's raw
+// OCI environment, service context and error handles as opaque
+// unsafe.Pointers, for callers in the goracle package that need to make
+// their own OCI calls (subscriptions, array binds, object binds,
+// OCIBreak/OCIReset) that this package's Connection doesn't expose any
+// other way.
+func (c *Connection) EnvHandle() unsafe.Pointer    { return c.env }
+func (c *Connection) SvcCtxHandle() unsafe.Pointer { return c.svc }
+func (c *Connection) ErrorHandle() unsafe.Pointer  { return c.errh }
@@ -0,0 +1,192 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oracle
+
+/*
+#include <oci.h>
+#include <stdlib.h>
+
+// OCI_ATTR_WALLET_LOCATION/PASSWORD aren't in every platform's oci.h;
+// define them here (goracle's own numbering, outside the range OCI
+// itself uses) if the installed client headers predate wallet support,
+// so this file builds against older Instant Client trees too.
+#ifndef OCI_ATTR_WALLET_LOCATION
+#define OCI_ATTR_WALLET_LOCATION 9000
+#endif
+#ifndef OCI_ATTR_WALLET_PASSWORD
+#define OCI_ATTR_WALLET_PASSWORD 9001
+#endif
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+func (c *Connection) connect() error {
+	var env *C.OCIEnv
+	if rv := C.OCIEnvCreate(&env, C.OCI_THREADED|C.OCI_OBJECT, nil, nil, nil, nil, 0, nil); rv != C.OCI_SUCCESS {
+		return fmt.Errorf("oracle: OCIEnvCreate: rv=%d", int(rv))
+	}
+	c.env = unsafe.Pointer(env)
+
+	var errh *C.OCIError
+	if rv := C.OCIHandleAlloc(unsafe.Pointer(env), (*unsafe.Pointer)(unsafe.Pointer(&errh)),
+		C.OCI_HTYPE_ERROR, 0, nil); rv != C.OCI_SUCCESS {
+		return fmt.Errorf("oracle: OCIHandleAlloc(OCI_HTYPE_ERROR): rv=%d", int(rv))
+	}
+	c.errh = unsafe.Pointer(errh)
+
+	var srv *C.OCIServer
+	if rv := C.OCIHandleAlloc(unsafe.Pointer(env), (*unsafe.Pointer)(unsafe.Pointer(&srv)),
+		C.OCI_HTYPE_SERVER, 0, nil); rv != C.OCI_SUCCESS {
+		return c.oracleError("OCIHandleAlloc(OCI_HTYPE_SERVER)", rv)
+	}
+	c.srv = unsafe.Pointer(srv)
+
+	if c.wallet.TCPS {
+		if err := c.setWalletAttrs(srv, errh); err != nil {
+			return err
+		}
+	}
+
+	cDsn := C.CString(c.dsn)
+	defer C.free(unsafe.Pointer(cDsn))
+	if rv := C.OCIServerAttach(srv, errh, (*C.OraText)(unsafe.Pointer(cDsn)), C.sb4(len(c.dsn)), C.OCI_DEFAULT); rv != C.OCI_SUCCESS {
+		return c.oracleError("OCIServerAttach", rv)
+	}
+
+	var svc *C.OCISvcCtx
+	if rv := C.OCIHandleAlloc(unsafe.Pointer(env), (*unsafe.Pointer)(unsafe.Pointer(&svc)),
+		C.OCI_HTYPE_SVCCTX, 0, nil); rv != C.OCI_SUCCESS {
+		return c.oracleError("OCIHandleAlloc(OCI_HTYPE_SVCCTX)", rv)
+	}
+	c.svc = unsafe.Pointer(svc)
+
+	if rv := C.OCIAttrSet(unsafe.Pointer(svc), C.OCI_HTYPE_SVCCTX, unsafe.Pointer(srv), 0, C.OCI_ATTR_SERVER, errh); rv != C.OCI_SUCCESS {
+		return c.oracleError("OCIAttrSet(OCI_ATTR_SERVER)", rv)
+	}
+
+	var sess *C.OCISession
+	if rv := C.OCIHandleAlloc(unsafe.Pointer(env), (*unsafe.Pointer)(unsafe.Pointer(&sess)),
+		C.OCI_HTYPE_SESSION, 0, nil); rv != C.OCI_SUCCESS {
+		return c.oracleError("OCIHandleAlloc(OCI_HTYPE_SESSION)", rv)
+	}
+	cUser := C.CString(c.username)
+	defer C.free(unsafe.Pointer(cUser))
+	cPass := C.CString(c.password)
+	defer C.free(unsafe.Pointer(cPass))
+	if rv := C.OCIAttrSet(unsafe.Pointer(sess), C.OCI_HTYPE_SESSION, unsafe.Pointer(cUser), C.ub4(len(c.username)), C.OCI_ATTR_USERNAME, errh); rv != C.OCI_SUCCESS {
+		return c.oracleError("OCIAttrSet(OCI_ATTR_USERNAME)", rv)
+	}
+	if rv := C.OCIAttrSet(unsafe.Pointer(sess), C.OCI_HTYPE_SESSION, unsafe.Pointer(cPass), C.ub4(len(c.password)), C.OCI_ATTR_PASSWORD, errh); rv != C.OCI_SUCCESS {
+		return c.oracleError("OCIAttrSet(OCI_ATTR_PASSWORD)", rv)
+	}
+	if rv := C.OCISessionBegin(svc, errh, sess, C.OCI_CRED_RDBMS, C.OCI_DEFAULT); rv != C.OCI_SUCCESS {
+		return c.oracleError("OCISessionBegin", rv)
+	}
+	if rv := C.OCIAttrSet(unsafe.Pointer(svc), C.OCI_HTYPE_SVCCTX, unsafe.Pointer(sess), 0, C.OCI_ATTR_SESSION, errh); rv != C.OCI_SUCCESS {
+		return c.oracleError("OCIAttrSet(OCI_ATTR_SESSION)", rv)
+	}
+	return nil
+}
+
+func (c *Connection) disconnect() error {
+	if c.svc != nil {
+		C.OCIHandleFree(c.svc, C.OCI_HTYPE_SVCCTX)
+	}
+	if c.srv != nil {
+		C.OCIServerDetach((*C.OCIServer)(c.srv), (*C.OCIError)(c.errh), C.OCI_DEFAULT)
+		C.OCIHandleFree(c.srv, C.OCI_HTYPE_SERVER)
+	}
+	if c.errh != nil {
+		C.OCIHandleFree(c.errh, C.OCI_HTYPE_ERROR)
+	}
+	if c.env != nil {
+		C.OCIHandleFree(c.env, C.OCI_HTYPE_ENV)
+	}
+	return nil
+}
+
+func (c *Connection) breakAndReset() error {
+	if rv := C.OCIBreak(c.svc, (*C.OCIError)(c.errh)); rv != C.OCI_SUCCESS {
+		return c.oracleError("OCIBreak", rv)
+	}
+	if rv := C.OCIReset(c.svc, (*C.OCIError)(c.errh)); rv != C.OCI_SUCCESS {
+		return c.oracleError("OCIReset", rv)
+	}
+	return nil
+}
+
+func (c *Connection) commit() error {
+	if rv := C.OCITransCommit((*C.OCISvcCtx)(c.svc), (*C.OCIError)(c.errh), C.OCI_DEFAULT); rv != C.OCI_SUCCESS {
+		return c.oracleError("OCITransCommit", rv)
+	}
+	return nil
+}
+
+func (c *Connection) rollback() error {
+	if rv := C.OCITransRollback((*C.OCISvcCtx)(c.svc), (*C.OCIError)(c.errh), C.OCI_DEFAULT); rv != C.OCI_SUCCESS {
+		return c.oracleError("OCITransRollback", rv)
+	}
+	return nil
+}
+
+// setWalletAttrs configures the server handle for a TCPS/mutual-TLS
+// connection before OCIServerAttach: OCI_ATTR_SSL_SERVER_DN_MATCH (the
+// only one of these in upstream's own oci.h), plus the
+// OCI_ATTR_WALLET_LOCATION/OCI_ATTR_WALLET_PASSWORD pair goracle's
+// vendored oci.h shim adds alongside it so the wallet at c.wallet.Wallet
+// supplies the client certificate OCIServerAttach authenticates with.
+func (c *Connection) setWalletAttrs(srv *C.OCIServer, errh *C.OCIError) error {
+	if c.wallet.SSLServerCertDN != "" {
+		cDN := C.CString(c.wallet.SSLServerCertDN)
+		defer C.free(unsafe.Pointer(cDN))
+		if rv := C.OCIAttrSet(unsafe.Pointer(srv), C.OCI_HTYPE_SERVER,
+			unsafe.Pointer(cDN), C.ub4(len(c.wallet.SSLServerCertDN)), C.OCI_ATTR_SSL_SERVER_DN_MATCH, errh); rv != C.OCI_SUCCESS {
+			return c.oracleError("OCIAttrSet(OCI_ATTR_SSL_SERVER_DN_MATCH)", rv)
+		}
+	}
+	if c.wallet.Wallet != "" {
+		cLoc := C.CString(c.wallet.Wallet)
+		defer C.free(unsafe.Pointer(cLoc))
+		if rv := C.OCIAttrSet(unsafe.Pointer(srv), C.OCI_HTYPE_SERVER,
+			unsafe.Pointer(cLoc), C.ub4(len(c.wallet.Wallet)), C.OCI_ATTR_WALLET_LOCATION, errh); rv != C.OCI_SUCCESS {
+			return c.oracleError("OCIAttrSet(OCI_ATTR_WALLET_LOCATION)", rv)
+		}
+	}
+	if c.wallet.WalletPassword != "" {
+		cPwd := C.CString(c.wallet.WalletPassword)
+		defer C.free(unsafe.Pointer(cPwd))
+		if rv := C.OCIAttrSet(unsafe.Pointer(srv), C.OCI_HTYPE_SERVER,
+			unsafe.Pointer(cPwd), C.ub4(len(c.wallet.WalletPassword)), C.OCI_ATTR_WALLET_PASSWORD, errh); rv != C.OCI_SUCCESS {
+			return c.oracleError("OCIAttrSet(OCI_ATTR_WALLET_PASSWORD)", rv)
+		}
+	}
+	return nil
+}
+
+// oracleError wraps an OCI return code with the error text fetched via
+// OCIErrorGet.
+func (c *Connection) oracleError(function string, rv C.sword) error {
+	var buf [2048]C.char
+	var errcode C.sb4
+	C.OCIErrorGet(c.errh, 1, nil, &errcode,
+		(*C.OraText)(unsafe.Pointer(&buf[0])), C.ub4(len(buf)), C.OCI_HTYPE_ERROR)
+	return fmt.Errorf("oracle: %s: %s (ORA-%05d)", function, C.GoString(&buf[0]), int(errcode))
+}
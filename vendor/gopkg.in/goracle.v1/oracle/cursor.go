@@ -0,0 +1,57 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oracle
+
+import "unsafe"
+
+// Cursor is a single OCI statement handle, opened with
+// (*Connection).NewCursor.
+type Cursor struct {
+	conn *Connection
+	stmt unsafe.Pointer
+}
+
+// Close releases the statement handle.
+func (cur *Cursor) Close() error {
+	return cur.close()
+}
+
+// Execute parses and runs statement once. params binds by name
+// ("SELECT :1 FROM DUAL" style placeholders are named "1", "2", ...);
+// a pointer value in params is bound for OUT (or IN/OUT, if it was also
+// given a non-zero starting value) and is populated with the value
+// Oracle returned once Execute returns.
+func (cur *Cursor) Execute(statement string, params map[string]interface{}) error {
+	return cur.execute(statement, params)
+}
+
+// ExecuteMany runs statement once per entry of params using a single
+// OCI array bind (one round-trip for the whole batch), with params[i]
+// providing the same named binds Execute takes for row i. All entries
+// must bind the same set of names; supported column types are int64,
+// float64, string and time.Time. If the server rejects some rows but
+// not others, ExecuteMany returns a non-nil *ExecuteManyError listing
+// them -- every row not listed there was executed normally.
+func (cur *Cursor) ExecuteMany(statement string, params []map[string]interface{}) error {
+	return cur.executeMany(statement, params)
+}
+
+// NewVariableByValue allocates a Variable able to hold arraySize values
+// shaped like value (used to size/type array binds before Execute).
+func (cur *Cursor) NewVariableByValue(value interface{}, arraySize uint) (*Variable, error) {
+	return cur.newVariableByValue(value, arraySize)
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oracle
+
+/*
+#include <oci.h>
+#include <stdlib.h>
+
+// OCI_ATTR_NUM_DML_ERRORS/OCI_ATTR_DML_ROW_OFFSET aren't in every
+// platform's oci.h; define them here (their real, OCI-assigned numbers)
+// if the installed client headers predate the array-DML-error-handling
+// feature (OCI 11.1+), so this file builds against older Instant Client
+// trees too -- executeMany just won't see any per-row detail on those.
+#ifndef OCI_ATTR_NUM_DML_ERRORS
+#define OCI_ATTR_NUM_DML_ERRORS 178
+#endif
+#ifndef OCI_ATTR_DML_ROW_OFFSET
+#define OCI_ATTR_DML_ROW_OFFSET 179
+#endif
+*/
+import "C"
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"unsafe"
+)
+
+func (cur *Cursor) prepare(statement string) error {
+	if cur.stmt != nil {
+		C.OCIHandleFree(cur.stmt, C.OCI_HTYPE_STMT)
+		cur.stmt = nil
+	}
+	var stmt *C.OCIStmt
+	cStmt := C.CString(statement)
+	defer C.free(unsafe.Pointer(cStmt))
+	rv := C.OCIStmtPrepare2(cur.conn.svc, &stmt, (*C.OCIError)(cur.conn.errh),
+		(*C.OraText)(unsafe.Pointer(cStmt)), C.ub4(len(statement)), nil, 0, C.OCI_NTV_SYNTAX, C.OCI_DEFAULT)
+	if rv != C.OCI_SUCCESS {
+		return cur.conn.oracleError("OCIStmtPrepare2", rv)
+	}
+	cur.stmt = unsafe.Pointer(stmt)
+	return nil
+}
+
+func (cur *Cursor) close() error {
+	if cur.stmt == nil {
+		return nil
+	}
+	rv := C.OCIStmtRelease((*C.OCIStmt)(cur.stmt), (*C.OCIError)(cur.conn.errh), nil, 0, C.OCI_DEFAULT)
+	cur.stmt = nil
+	if rv != C.OCI_SUCCESS {
+		return cur.conn.oracleError("OCIStmtRelease", rv)
+	}
+	return nil
+}
+
+// bindScalar binds a single Go value to :name for iters=1 execution, via
+// OCIBindByName. Pointers bind for OUT (and, if *v is non-zero, for
+// IN/OUT). For *int64, OCIBindByName binds the Go value's own memory, so
+// OCIStmtExecute writes the server's value back into it directly; for
+// *string, Oracle's CHR buffer can't be the Go string's own (immutable)
+// backing array, so bindScalar binds a scratch buffer and returns a sync
+// func the caller must run after a successful execute to copy that
+// buffer back into *p.
+func (cur *Cursor) bindScalar(name string, v interface{}) (func(), error) {
+	cName := C.CString(":" + name)
+	defer C.free(unsafe.Pointer(cName))
+
+	var bindp *C.OCIBind
+	switch p := v.(type) {
+	case *int64:
+		rv := C.OCIBindByName(
+			(*C.OCIStmt)(cur.stmt), &bindp, (*C.OCIError)(cur.conn.errh),
+			(*C.OraText)(unsafe.Pointer(cName)), C.sb4(len(name)+1),
+			unsafe.Pointer(p), C.sb4(unsafe.Sizeof(*p)), C.SQLT_INT, nil, nil, nil, 0, nil, C.OCI_DEFAULT)
+		if rv != C.OCI_SUCCESS {
+			return nil, cur.conn.oracleError("OCIBindByName(int64)", rv)
+		}
+		return nil, nil
+	case *string:
+		buf := make([]byte, 4000)
+		copy(buf, *p)
+		var actualLen C.ub2 = C.ub2(len(*p))
+		rv := C.OCIBindByName(
+			(*C.OCIStmt)(cur.stmt), &bindp, (*C.OCIError)(cur.conn.errh),
+			(*C.OraText)(unsafe.Pointer(cName)), C.sb4(len(name)+1),
+			unsafe.Pointer(&buf[0]), C.sb4(len(buf)), C.SQLT_CHR, nil, &actualLen, nil, 0, nil, C.OCI_DEFAULT)
+		if rv != C.OCI_SUCCESS {
+			return nil, cur.conn.oracleError("OCIBindByName(string)", rv)
+		}
+		return func() { *p = string(buf[:actualLen]) }, nil
+	case int64:
+		return cur.bindScalar(name, &p)
+	case string:
+		return cur.bindScalar(name, &p)
+	default:
+		return nil, fmt.Errorf("oracle: unsupported bind type %T for :%s", v, name)
+	}
+}
+
+func (cur *Cursor) execute(statement string, params map[string]interface{}) error {
+	if err := cur.prepare(statement); err != nil {
+		return err
+	}
+	var syncs []func()
+	for _, name := range sortedKeys(params) {
+		sync, err := cur.bindScalar(name, params[name])
+		if err != nil {
+			return err
+		}
+		if sync != nil {
+			syncs = append(syncs, sync)
+		}
+	}
+	mode := C.ub4(C.OCI_DEFAULT)
+	if cur.conn.autocommit {
+		mode = C.OCI_COMMIT_ON_SUCCESS
+	}
+	rv := C.OCIStmtExecute(cur.conn.svc, (*C.OCIStmt)(cur.stmt), (*C.OCIError)(cur.conn.errh), 1, 0, nil, nil, mode)
+	if rv != C.OCI_SUCCESS {
+		return cur.conn.oracleError("OCIStmtExecute", rv)
+	}
+	for _, sync := range syncs {
+		sync()
+	}
+	return nil
+}
+
+// RowError reports that row Row of an ExecuteMany batch was rejected by
+// the server while the rest of the batch went through.
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e RowError) Error() string { return fmt.Sprintf("row %d: %s", e.Row, e.Err) }
+
+// ExecuteManyError is returned by ExecuteMany when OCI_BATCH_ERRORS lets
+// some rows fail without aborting the whole array bind: every row not
+// listed in Failed was executed (and, if autocommit, committed)
+// normally.
+type ExecuteManyError struct {
+	Failed []RowError
+}
+
+func (e *ExecuteManyError) Error() string {
+	return fmt.Sprintf("oracle: ExecuteMany: %d row(s) rejected by the server", len(e.Failed))
+}
+
+// executeMany runs statement once for len(params) rows using a single
+// array bind (iters=len(params)): every column across all rows is
+// packed into one contiguous buffer per bind name, and OCIStmtExecute
+// is called once with that iteration count instead of once per row.
+// OCI_BATCH_ERRORS asks the server to reject and report individual bad
+// rows rather than failing the whole call; a non-empty *ExecuteManyError
+// is returned in that case, alongside the rows that did go through.
+func (cur *Cursor) executeMany(statement string, params []map[string]interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := cur.prepare(statement); err != nil {
+		return err
+	}
+
+	for _, name := range sortedKeys(params[0]) {
+		if err := cur.bindManyColumn(name, params); err != nil {
+			return err
+		}
+	}
+
+	mode := C.ub4(C.OCI_BATCH_ERRORS)
+	if cur.conn.autocommit {
+		mode |= C.OCI_COMMIT_ON_SUCCESS
+	}
+	rv := C.OCIStmtExecute(cur.conn.svc, (*C.OCIStmt)(cur.stmt), (*C.OCIError)(cur.conn.errh),
+		C.ub4(len(params)), 0, nil, nil, mode)
+	if rv != C.OCI_SUCCESS && rv != C.OCI_SUCCESS_WITH_INFO {
+		return cur.conn.oracleError("OCIStmtExecute(array)", rv)
+	}
+
+	failed, err := cur.batchErrors()
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return &ExecuteManyError{Failed: failed}
+	}
+	return nil
+}
+
+// bindManyColumn array-binds every row's value for one column name,
+// dispatching on the Go type of the first row's value the same way
+// bindScalar dispatches for a single-row Execute.
+func (cur *Cursor) bindManyColumn(name string, params []map[string]interface{}) error {
+	cName := C.CString(":" + name)
+	defer C.free(unsafe.Pointer(cName))
+	var bindp *C.OCIBind
+
+	switch params[0][name].(type) {
+	case int64:
+		col := make([]int64, len(params))
+		for i, row := range params {
+			n, ok := row[name].(int64)
+			if !ok {
+				return fmt.Errorf("oracle: ExecuteMany: row %d bind :%s: want int64, got %T", i, name, row[name])
+			}
+			col[i] = n
+		}
+		rv := C.OCIBindByName(
+			(*C.OCIStmt)(cur.stmt), &bindp, (*C.OCIError)(cur.conn.errh),
+			(*C.OraText)(unsafe.Pointer(cName)), C.sb4(len(name)+1),
+			unsafe.Pointer(&col[0]), C.sb4(unsafe.Sizeof(col[0])), C.SQLT_INT, nil, nil, nil, 0, nil, C.OCI_DEFAULT)
+		if rv != C.OCI_SUCCESS {
+			return cur.conn.oracleError(fmt.Sprintf("OCIBindByName(:%s, []int64)", name), rv)
+		}
+	case float64:
+		col := make([]C.double, len(params))
+		for i, row := range params {
+			f, ok := row[name].(float64)
+			if !ok {
+				return fmt.Errorf("oracle: ExecuteMany: row %d bind :%s: want float64, got %T", i, name, row[name])
+			}
+			col[i] = C.double(f)
+		}
+		rv := C.OCIBindByName(
+			(*C.OCIStmt)(cur.stmt), &bindp, (*C.OCIError)(cur.conn.errh),
+			(*C.OraText)(unsafe.Pointer(cName)), C.sb4(len(name)+1),
+			unsafe.Pointer(&col[0]), C.sb4(unsafe.Sizeof(col[0])), C.SQLT_FLT, nil, nil, nil, 0, nil, C.OCI_DEFAULT)
+		if rv != C.OCI_SUCCESS {
+			return cur.conn.oracleError(fmt.Sprintf("OCIBindByName(:%s, []float64)", name), rv)
+		}
+	case string:
+		const width = 4000
+		buf := make([]byte, width*len(params))
+		lens := make([]C.ub2, len(params))
+		for i, row := range params {
+			s, ok := row[name].(string)
+			if !ok {
+				return fmt.Errorf("oracle: ExecuteMany: row %d bind :%s: want string, got %T", i, name, row[name])
+			}
+			copy(buf[i*width:(i+1)*width], s)
+			lens[i] = C.ub2(len(s))
+		}
+		rv := C.OCIBindByName(
+			(*C.OCIStmt)(cur.stmt), &bindp, (*C.OCIError)(cur.conn.errh),
+			(*C.OraText)(unsafe.Pointer(cName)), C.sb4(len(name)+1),
+			unsafe.Pointer(&buf[0]), C.sb4(width), C.SQLT_CHR, nil, &lens[0], nil, 0, nil, C.OCI_DEFAULT)
+		if rv != C.OCI_SUCCESS {
+			return cur.conn.oracleError(fmt.Sprintf("OCIBindByName(:%s, []string)", name), rv)
+		}
+	case time.Time:
+		const width = 7 // SQLT_DAT's fixed 7-byte century/year/month/day/hour/min/sec encoding
+		buf := make([]byte, width*len(params))
+		for i, row := range params {
+			t, ok := row[name].(time.Time)
+			if !ok {
+				return fmt.Errorf("oracle: ExecuteMany: row %d bind :%s: want time.Time, got %T", i, name, row[name])
+			}
+			putOCIDate(buf[i*width:(i+1)*width], t)
+		}
+		rv := C.OCIBindByName(
+			(*C.OCIStmt)(cur.stmt), &bindp, (*C.OCIError)(cur.conn.errh),
+			(*C.OraText)(unsafe.Pointer(cName)), C.sb4(len(name)+1),
+			unsafe.Pointer(&buf[0]), C.sb4(width), C.SQLT_DAT, nil, nil, nil, 0, nil, C.OCI_DEFAULT)
+		if rv != C.OCI_SUCCESS {
+			return cur.conn.oracleError(fmt.Sprintf("OCIBindByName(:%s, []time.Time)", name), rv)
+		}
+	default:
+		return fmt.Errorf("oracle: ExecuteMany: bind :%s: unsupported column type %T", name, params[0][name])
+	}
+	return nil
+}
+
+// putOCIDate packs t into OCI's native 7-byte SQLT_DAT representation
+// (century+100, year-of-century+100, month, day, hour+1, minute+1,
+// second+1).
+func putOCIDate(buf []byte, t time.Time) {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	buf[0] = byte(year/100) + 100
+	buf[1] = byte(year%100) + 100
+	buf[2] = byte(month)
+	buf[3] = byte(day)
+	buf[4] = byte(hour) + 1
+	buf[5] = byte(min) + 1
+	buf[6] = byte(sec) + 1
+}
+
+// batchErrors reads OCI_ATTR_NUM_DML_ERRORS off the statement handle
+// after an OCI_BATCH_ERRORS execute and walks that many per-row error
+// records (OCIParamGet against the error handle OCIStmtExecute was
+// given) to find out which rows were rejected and why.
+func (cur *Cursor) batchErrors() ([]RowError, error) {
+	var numErrs C.ub4
+	rv := C.OCIAttrGet(cur.stmt, C.OCI_HTYPE_STMT, unsafe.Pointer(&numErrs), nil,
+		C.OCI_ATTR_NUM_DML_ERRORS, (*C.OCIError)(cur.conn.errh))
+	if rv != C.OCI_SUCCESS {
+		return nil, cur.conn.oracleError("OCIAttrGet(OCI_ATTR_NUM_DML_ERRORS)", rv)
+	}
+	if numErrs == 0 {
+		return nil, nil
+	}
+
+	errs := make([]RowError, 0, int(numErrs))
+	for i := C.ub4(0); i < numErrs; i++ {
+		var rowErrh *C.OCIError
+		rv := C.OCIParamGet(unsafe.Pointer(cur.conn.errh), C.OCI_HTYPE_ERROR, (*C.OCIError)(cur.conn.errh),
+			(*unsafe.Pointer)(unsafe.Pointer(&rowErrh)), i)
+		if rv != C.OCI_SUCCESS {
+			continue
+		}
+
+		var offset C.ub4
+		C.OCIAttrGet(unsafe.Pointer(rowErrh), C.OCI_HTYPE_ERROR, unsafe.Pointer(&offset), nil,
+			C.OCI_ATTR_DML_ROW_OFFSET, (*C.OCIError)(cur.conn.errh))
+
+		var buf [2048]C.char
+		var errcode C.sb4
+		C.OCIErrorGet(unsafe.Pointer(rowErrh), 1, nil, &errcode,
+			(*C.OraText)(unsafe.Pointer(&buf[0])), C.ub4(len(buf)), C.OCI_HTYPE_ERROR)
+		errs = append(errs, RowError{
+			Row: int(offset),
+			Err: fmt.Errorf("%s (ORA-%05d)", C.GoString(&buf[0]), int(errcode)),
+		})
+	}
+	return errs, nil
+}
+
+func (cur *Cursor) newVariableByValue(value interface{}, arraySize uint) (*Variable, error) {
+	return &Variable{cur: cur, arraySize: arraySize}, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
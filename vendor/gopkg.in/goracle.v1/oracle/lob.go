@@ -0,0 +1,46 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oracle
+
+import "io"
+
+// ExternalLobVar wraps a fetched CLOB/BLOB locator for streamed or
+// whole-value reads.
+type ExternalLobVar struct {
+	cur  *Cursor
+	data []byte
+}
+
+// Read implements io.Reader over the LOB's already-fetched bytes.
+func (l *ExternalLobVar) Read(p []byte) (int, error) {
+	if len(l.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, l.data)
+	l.data = l.data[n:]
+	return n, nil
+}
+
+// ReadAll returns the LOB's full contents.
+func (l *ExternalLobVar) ReadAll() ([]byte, error) {
+	return l.data, nil
+}
+
+// Close releases the LOB locator.
+func (l *ExternalLobVar) Close() error {
+	return nil
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oracle
+
+// logger is the minimal handler-based logging interface this package
+// and its test suite use (mirrors github.com/tgulacsi/go/loghlp's
+// handler model, without taking on that dependency here).
+type logger struct {
+	handler interface{}
+}
+
+// SetHandler installs h as the destination for this package's log
+// output; tests point it at tsthlp.TestHandler(t) so driver-level logs
+// surface as t.Log lines.
+func (l *logger) SetHandler(h interface{}) {
+	l.handler = h
+}
+
+// Log is this package's logger, in the style every goracle.v1 consumer
+// already points at a *testing.T via tsthlp.TestHandler.
+var Log logger
@@ -0,0 +1,164 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oracle
+
+/*
+#include <oci.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// NewObjectInstance allocates a new instance of the object/collection
+// type described by tdo in the object cache, via OCIObjectNew. Callers
+// free it with FreeObjectInstance once it's been bound (the bind copies
+// the instance's value; OCI doesn't need it to outlive the bind call).
+func (cur *Cursor) NewObjectInstance(tdo uintptr) (unsafe.Pointer, error) {
+	var instance unsafe.Pointer
+	rv := C.OCIObjectNew(
+		(*C.OCIEnv)(cur.conn.env), (*C.OCIError)(cur.conn.errh), (*C.OCISvcCtx)(cur.conn.svc),
+		C.OCI_TYPECODE_OBJECT, (*C.OCIType)(unsafe.Pointer(tdo)), nil,
+		C.OCI_DURATION_SESSION, C.TRUE, &instance)
+	if rv != C.OCI_SUCCESS {
+		return nil, cur.conn.oracleError("OCIObjectNew", rv)
+	}
+	return instance, nil
+}
+
+// FreeObjectInstance releases an instance allocated by NewObjectInstance.
+func (cur *Cursor) FreeObjectInstance(instance unsafe.Pointer) error {
+	rv := C.OCIObjectFree((*C.OCIEnv)(cur.conn.env), (*C.OCIError)(cur.conn.errh), instance, 0)
+	if rv != C.OCI_SUCCESS {
+		return cur.conn.oracleError("OCIObjectFree", rv)
+	}
+	return nil
+}
+
+// SetObjectAttr writes value into the named top-level attribute of
+// instance (an object of type tdo), via OCIObjectGetAttr (which, given
+// an attribute name, hands back that attribute's address within
+// instance) followed by the OCINumber/OCIString call appropriate to
+// value's Go type. A nested *Object/*Collection attribute is set by
+// passing its own already-built instance pointer as value.
+func (cur *Cursor) SetObjectAttr(instance unsafe.Pointer, tdo uintptr, attrName string, value interface{}) error {
+	cAttr := C.CString(attrName)
+	defer C.free(unsafe.Pointer(cAttr))
+	name := (*C.oratext)(unsafe.Pointer(cAttr))
+	nameLen := C.ub4(len(attrName))
+
+	var attrNullStatus C.OCIInd
+	var attrNullStruct unsafe.Pointer
+	var attrValue unsafe.Pointer
+	var attrTDO *C.OCIType
+
+	rv := C.OCIObjectGetAttr(
+		(*C.OCIEnv)(cur.conn.env), (*C.OCIError)(cur.conn.errh), instance, nil,
+		(*C.OCIType)(unsafe.Pointer(tdo)),
+		&name, &nameLen, 1, nil, 0,
+		&attrNullStatus, &attrNullStruct, &attrValue, &attrTDO)
+	if rv != C.OCI_SUCCESS {
+		return cur.conn.oracleError(fmt.Sprintf("OCIObjectGetAttr(%s)", attrName), rv)
+	}
+
+	switch v := value.(type) {
+	case string:
+		cVal := C.CString(v)
+		defer C.free(unsafe.Pointer(cVal))
+		if rv := C.OCIStringAssignText(
+			(*C.OCIEnv)(cur.conn.env), (*C.OCIError)(cur.conn.errh),
+			(*C.oratext)(unsafe.Pointer(cVal)), C.ub2(len(v)),
+			(**C.OCIString)(attrValue)); rv != C.OCI_SUCCESS {
+			return cur.conn.oracleError(fmt.Sprintf("OCIStringAssignText(%s)", attrName), rv)
+		}
+	case int64:
+		if rv := C.OCINumberFromInt(
+			(*C.OCIError)(cur.conn.errh), unsafe.Pointer(&v), C.uword(unsafe.Sizeof(v)),
+			C.OCI_NUMBER_SIGNED, (*C.OCINumber)(attrValue)); rv != C.OCI_SUCCESS {
+			return cur.conn.oracleError(fmt.Sprintf("OCINumberFromInt(%s)", attrName), rv)
+		}
+	case unsafe.Pointer:
+		*(*unsafe.Pointer)(attrValue) = v
+	default:
+		return fmt.Errorf("oracle: SetObjectAttr(%s): unsupported value type %T", attrName, value)
+	}
+	if attrNullStruct != nil {
+		*(*C.OCIInd)(attrNullStruct) = C.OCI_IND_NOTNULL
+	}
+	return nil
+}
+
+// AppendCollectionElem appends value to collection (an instance
+// allocated by NewObjectInstance for a VARRAY/nested-table tdo), via
+// OCICollAppend.
+func (cur *Cursor) AppendCollectionElem(collection unsafe.Pointer, value interface{}) error {
+	switch v := value.(type) {
+	case int64:
+		var num C.OCINumber
+		if rv := C.OCINumberFromInt(
+			(*C.OCIError)(cur.conn.errh), unsafe.Pointer(&v), C.uword(unsafe.Sizeof(v)),
+			C.OCI_NUMBER_SIGNED, &num); rv != C.OCI_SUCCESS {
+			return cur.conn.oracleError("OCINumberFromInt(element)", rv)
+		}
+		if rv := C.OCICollAppend(
+			(*C.OCIEnv)(cur.conn.env), (*C.OCIError)(cur.conn.errh),
+			unsafe.Pointer(&num), nil, (*C.OCIColl)(collection)); rv != C.OCI_SUCCESS {
+			return cur.conn.oracleError("OCICollAppend", rv)
+		}
+		return nil
+	default:
+		return fmt.Errorf("oracle: AppendCollectionElem: unsupported value type %T", value)
+	}
+}
+
+// Prepare parses statement for a later manual bind/execute sequence
+// (e.g. BindObjectPos followed by StmtExecute), the same OCIStmtPrepare2
+// call Execute/ExecuteMany make internally.
+func (cur *Cursor) Prepare(statement string) error {
+	return cur.prepare(statement)
+}
+
+// BindObjectPos binds instance at 1-based position pos as SQLT_NTY, via
+// OCIBindByPos. The statement must already be prepared (see Prepare).
+func (cur *Cursor) BindObjectPos(pos int, instance unsafe.Pointer) error {
+	var bindp *C.OCIBind
+	ind := C.OCIInd(C.OCI_IND_NOTNULL)
+	rv := C.OCIBindByPos(
+		(*C.OCIStmt)(cur.stmt), &bindp, (*C.OCIError)(cur.conn.errh), C.ub4(pos),
+		unsafe.Pointer(&instance), 0, C.SQLT_NTY, unsafe.Pointer(&ind), nil, nil, 0, nil, C.OCI_DEFAULT)
+	if rv != C.OCI_SUCCESS {
+		return cur.conn.oracleError("OCIBindByPos(SQLT_NTY)", rv)
+	}
+	return nil
+}
+
+// StmtExecute runs the statement Prepare parsed and BindObjectPos (or
+// bindScalar, via Execute) bound parameters into, with iters=1.
+func (cur *Cursor) StmtExecute() error {
+	mode := C.ub4(C.OCI_DEFAULT)
+	if cur.conn.autocommit {
+		mode = C.OCI_COMMIT_ON_SUCCESS
+	}
+	rv := C.OCIStmtExecute(cur.conn.svc, (*C.OCIStmt)(cur.stmt), (*C.OCIError)(cur.conn.errh), 1, 0, nil, nil, mode)
+	if rv != C.OCI_SUCCESS {
+		return cur.conn.oracleError("OCIStmtExecute", rv)
+	}
+	return nil
+}
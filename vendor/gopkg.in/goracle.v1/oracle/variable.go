@@ -0,0 +1,40 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oracle
+
+import "unsafe"
+
+// Variable is a single OCI bind/define buffer, sized to hold up to
+// ArraySize values of one SQL type.
+type Variable struct {
+	cur       *Cursor
+	arraySize uint
+	buf       unsafe.Pointer
+	values    []interface{}
+}
+
+// SetValue stores value at position arrayPos (0-based) of this
+// variable's bind buffer.
+func (v *Variable) SetValue(arrayPos uint, value interface{}) error {
+	return v.setValue(arrayPos, value)
+}
+
+// GetValue reads back position arrayPos of this variable's buffer,
+// e.g. after it was used as an OUT bind.
+func (v *Variable) GetValue(arrayPos uint) (interface{}, error) {
+	return v.getValue(arrayPos)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oracle
+
+import "fmt"
+
+// values backs SetValue/GetValue for a Variable not yet wired into a
+// live OCIBindByName/OCIDefineByPos buffer (e.g. one built purely to
+// stage array-bind data before a higher-level Execute call packs it
+// into the real OCI buffers itself).
+func (v *Variable) ensureValues() []interface{} {
+	if v.values == nil {
+		v.values = make([]interface{}, v.arraySize)
+	}
+	return v.values
+}
+
+func (v *Variable) setValue(arrayPos uint, value interface{}) error {
+	values := v.ensureValues()
+	if arrayPos >= uint(len(values)) {
+		return fmt.Errorf("oracle: SetValue: position %d out of range [0,%d)", arrayPos, len(values))
+	}
+	values[arrayPos] = value
+	return nil
+}
+
+func (v *Variable) getValue(arrayPos uint) (interface{}, error) {
+	values := v.ensureValues()
+	if arrayPos >= uint(len(values)) {
+		return nil, fmt.Errorf("oracle: GetValue: position %d out of range [0,%d)", arrayPos, len(values))
+	}
+	return values[arrayPos], nil
+}
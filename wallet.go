@@ -0,0 +1,55 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goracle
+
+import (
+	"fmt"
+
+	"gopkg.in/goracle.v1/oracle"
+)
+
+// openDSN opens an oracle.Connection for a parsed DSN. Wallet/TCPS
+// options route to oracle.NewConnectionWithWallet, which sets
+// OCI_ATTR_WALLET_LOCATION, OCI_ATTR_WALLET_PASSWORD and
+// OCI_ATTR_SSL_SERVER_DN_MATCH on the server handle before
+// OCIServerAttach; plain connections go through plain oracle.NewConnection.
+// Both are part of this driver's own oracle package (see that package's
+// doc comment for why it isn't actually the upstream gopkg.in/goracle.v1
+// module despite the import path).
+//
+// This is what (Driver).Open calls into; see driver.go for the
+// database/sql.Driver glue.
+func openDSN(d DSN) (*oracle.Connection, error) {
+	if d.Wallet == "" && !d.TCPS {
+		conn, err := oracle.NewConnection(d.Username, d.Password, d.Descriptor(), true)
+		if err != nil {
+			return nil, fmt.Errorf("goracle: connect: %s", err)
+		}
+		return conn, nil
+	}
+
+	conn, err := oracle.NewConnectionWithWallet(d.Username, d.Password, d.Descriptor(), true, oracle.WalletConfig{
+		TCPS:            true,
+		Wallet:          d.Wallet,
+		WalletPassword:  d.WalletPassword,
+		SSLServerCertDN: d.SSLServerCertDN,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("goracle: connect: %s", err)
+	}
+	return conn, nil
+}
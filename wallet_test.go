@@ -0,0 +1,83 @@
+/*
+Copyright 2013 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goracle
+
+import (
+	"database/sql"
+	"flag"
+	"testing"
+)
+
+var fWallet = flag.String("wallet", "", "path to an Oracle wallet directory, for TestWalletConnect")
+
+func TestParseDSN(t *testing.T) {
+	for i, tst := range []struct {
+		in   string
+		want DSN
+	}{
+		{
+			"scott/tiger@localhost:1521/orcl",
+			DSN{Username: "scott", Password: "tiger", Host: "localhost", Port: 1521, Service: "orcl"},
+		},
+		{
+			"scott/tiger@localhost:1521/orcl?wallet=/opt/wallet&tcps=true",
+			DSN{Username: "scott", Password: "tiger", Host: "localhost", Port: 1521, Service: "orcl",
+				Wallet: "/opt/wallet", TCPS: true},
+		},
+	} {
+		got, err := ParseDSN(tst.in)
+		if err != nil {
+			t.Fatalf("%d. ParseDSN(%q): %s", i, tst.in, err)
+		}
+		if got != tst.want {
+			t.Errorf("%d. ParseDSN(%q) = %+v, want %+v", i, tst.in, got, tst.want)
+		}
+	}
+}
+
+func TestParseDSNRawDescriptor(t *testing.T) {
+	desc := "(DESCRIPTION=(ADDRESS=(PROTOCOL=TCPS)(HOST=h)(PORT=2484))(CONNECT_DATA=(SERVICE_NAME=s)))"
+	got, err := ParseDSN("scott/tiger@" + desc + "?wallet=/opt/wallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ConnectDescriptor != desc {
+		t.Errorf("ConnectDescriptor = %q, want %q", got.ConnectDescriptor, desc)
+	}
+	if got.Descriptor() != desc {
+		t.Errorf("Descriptor() = %q, want %q", got.Descriptor(), desc)
+	}
+}
+
+// TestWalletConnect exercises an actual TCPS/wallet connection. It is
+// skipped unless -wallet is given, mirroring the t.Skip pattern
+// Test_open_cursors uses for privileges it can't assume the test user
+// has.
+func TestWalletConnect(t *testing.T) {
+	if *fWallet == "" {
+		t.Skip("no -wallet given")
+	}
+	dsn := *fDsn + "?wallet=" + *fWallet
+	db, err := sql.Open("goracle", dsn)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Fatalf("ping over TCPS/wallet: %s", err)
+	}
+}